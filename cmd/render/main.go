@@ -3,6 +3,10 @@ package main
 
 import "github.com/wernerstrydom/render/internal/cli"
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
+	cli.SetVersion(version)
 	cli.Execute()
 }
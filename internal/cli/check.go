@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/manifest"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/render"
+)
+
+// Check mode actions, reported per file.
+const (
+	checkCreate    = "create"    // Output does not exist on disk
+	checkChange    = "change"    // Output exists with different content
+	checkStale     = "stale"     // File an earlier render wrote that is no longer generated
+	checkUnchanged = "unchanged" // Output matches what is on disk
+)
+
+// compareOutputs runs --check, or --dry-run --diff, on the planned outputs.
+// Both compare against disk instead of going through the collision checks,
+// since reporting changed files is their purpose. skipped lists the template
+// entries the plans left out, which --diff reports. root is the directory of
+// the manifest, or empty in the single-file modes, which don't keep one.
+func compareOutputs(cmd *cobra.Command, w *output.Writer, outputs []render.Output, skipped []fileAction, root string) error {
+	if flags.check {
+		return runCheck(cmd, w, outputs, root)
+	}
+	return reportDiff(cmd, w, outputs, skipped, root)
+}
+
+// runCheck compares planned outputs against disk without writing anything.
+// Files the manifest in root records that are no longer generated are
+// reported as stale. Returns an ExitCheckFailed error if anything is out of
+// date.
+func runCheck(cmd *cobra.Command, w *output.Writer, outputs []render.Output, root string) error {
+	var actions []fileAction
+	for _, out := range outputs {
+		action, err := checkOutput(w, out)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, fileAction{Path: out.OutputPath, Action: action})
+	}
+
+	stale, err := findStale(w, root, outputs)
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		actions = append(actions, fileAction{Path: path, Action: checkStale})
+	}
+
+	return reportCheck(cmd, actions)
+}
+
+// checkOutput returns the check action for one output, comparing content
+// the same way checkCollision does.
+func checkOutput(w *output.Writer, out render.Output) (string, error) {
+	content, err := writtenContent(w, out)
+	if err != nil {
+		return "", err
+	}
+
+	switch compareFile(out.OutputPath, content) {
+	case fileMissing:
+		return checkCreate, nil
+	case fileIdentical:
+		return checkUnchanged, nil
+	default:
+		// Files that must not be overwritten are owned by the user once they exist
+		if !out.Overwrite {
+			return checkUnchanged, nil
+		}
+		return checkChange, nil
+	}
+}
+
+// outputContent returns the content an output would have on disk, reading
+// copied files from the template source.
+func outputContent(out render.Output) ([]byte, error) {
+	if out.CopyFrom == "" {
+		return out.Content, nil
+	}
+	content, err := os.ReadFile(out.CopyFrom)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitInputValidation,
+			msg:  fmt.Sprintf("failed to read template file: %v", err),
+			err:  err,
+		}
+	}
+	return content, nil
+}
+
+// writtenContent returns the content writing an output would leave on disk:
+// its rendered or copied content with any protected regions of the existing
+// file merged in.
+func writtenContent(w *output.Writer, out render.Output) ([]byte, error) {
+	content, err := outputContent(out)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := w.Merge(out.OutputPath, content)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  err.Error(),
+			err:  err,
+		}
+	}
+	return merged, nil
+}
+
+// findStale returns the files the manifest in root records that outputs no
+// longer produce and that --prune would delete, sorted by path. Files render
+// didn't write, or that were edited since, are never stale.
+func findStale(w *output.Writer, root string, outputs []render.Output) ([]string, error) {
+	if root == "" {
+		return nil, nil
+	}
+	prev, err := loadManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	next, err := newManifest(w, root, outputs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := manifest.Prune(root, prev, next, nil)
+	if err != nil {
+		return nil, wrapWriteError(err, "")
+	}
+
+	stale := make([]string, len(result.Removed))
+	for i, path := range result.Removed {
+		stale[i] = filepath.Join(root, filepath.FromSlash(path))
+	}
+	return stale, nil
+}
+
+// reportCheck reports check results and fails if any file is out of date.
+func reportCheck(cmd *cobra.Command, actions []fileAction) error {
+	outOfDate := 0
+	for _, a := range actions {
+		if a.Action != checkUnchanged {
+			outOfDate++
+		}
+	}
+
+	status := "up-to-date"
+	if outOfDate > 0 {
+		status = "out-of-date"
+	}
+
+	if flags.jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(renderResult{Status: status, Files: actions}); err != nil {
+			return err
+		}
+	} else {
+		for _, a := range actions {
+			if a.Action != checkUnchanged {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "  [%s] %s\n", a.Action, a.Path)
+			}
+		}
+	}
+
+	if outOfDate > 0 {
+		return &exitError{
+			code:     ExitCheckFailed,
+			msg:      fmt.Sprintf("%d generated file(s) out of date", outOfDate),
+			reported: true,
+		}
+	}
+
+	if !flags.jsonOut {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Up to date: %d file(s)\n", len(actions))
+	}
+	return nil
+}
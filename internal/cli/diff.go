@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/diff"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/render"
+)
+
+// diffSummary counts files by how they would change.
+type diffSummary struct {
+	New       int `json:"new"`
+	Changed   int `json:"changed"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// reportDiff reports, for --dry-run --diff, a unified diff between each
+// existing file and its rendered content, followed by a summary. skipped
+// lists the template entries left out, as skippedActions reports them.
+// With --prune, the files the manifest in root records that would be
+// deleted are shown as deletions.
+func reportDiff(cmd *cobra.Command, w *output.Writer, outputs []render.Output, skipped []fileAction, root string) error {
+	var summary diffSummary
+	actions := make([]fileAction, 0, len(outputs)+len(skipped))
+
+	for _, out := range outputs {
+		content, err := writtenContent(w, out)
+		if err != nil {
+			return err
+		}
+
+		action := fileAction{Path: out.OutputPath}
+		switch compareFile(out.OutputPath, content) {
+		case fileMissing:
+			action.Action = "create"
+			action.Diff = diff.Unified("/dev/null", out.OutputPath, nil, content)
+			summary.New++
+		case fileIdentical:
+			action.Action = "unchanged"
+			summary.Unchanged++
+		default:
+			if !out.Overwrite {
+				action.Action = "skip (exists, no-overwrite)"
+				summary.Unchanged++
+				break
+			}
+			existing, err := os.ReadFile(out.OutputPath)
+			if err != nil {
+				return &exitError{
+					code: ExitRuntimeError,
+					msg:  fmt.Sprintf("failed to read existing file: %v", err),
+					err:  err,
+				}
+			}
+			action.Action = "change"
+			action.Diff = diff.Unified(out.OutputPath, out.OutputPath, existing, content)
+			summary.Changed++
+		}
+		actions = append(actions, action)
+	}
+
+	actions = append(actions, skipped...)
+	summary.Skipped = len(skipped)
+
+	deleted, err := diffPrune(w, root, outputs)
+	if err != nil {
+		return err
+	}
+	for _, a := range deleted {
+		if a.Action == diffDelete {
+			summary.Deleted++
+		}
+	}
+	actions = append(actions, deleted...)
+
+	if flags.jsonOut {
+		result := renderResult{
+			Status:  "dry-run",
+			Files:   actions,
+			Summary: &summary,
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // keep diff content readable
+		return enc.Encode(result)
+	}
+
+	stdout := cmd.OutOrStdout()
+	for _, a := range actions {
+		_, _ = fmt.Fprint(stdout, a.Diff)
+	}
+	// Entries without a diff that aren't counted as unchanged are listed
+	for _, a := range actions {
+		if a.Diff == "" && a.Action != "unchanged" && a.Action != "skip (exists, no-overwrite)" {
+			_, _ = fmt.Fprintf(stdout, "  [%s] %s\n", a.Action, a.Path)
+		}
+	}
+	line := fmt.Sprintf("Dry run: %d new, %d changed, %d unchanged", summary.New, summary.Changed, summary.Unchanged)
+	if summary.Deleted > 0 {
+		line += fmt.Sprintf(", %d deleted", summary.Deleted)
+	}
+	if summary.Skipped > 0 {
+		line += fmt.Sprintf(", %d skipped", summary.Skipped)
+	}
+	_, _ = fmt.Fprintln(stdout, line)
+	return nil
+}
+
+// diffDelete is the --diff action for a file --prune would delete.
+const diffDelete = "delete"
+
+// diffPrune returns, with --prune, the files the manifest in root records
+// that outputs no longer produce: those --prune would delete, with a diff
+// against /dev/null, and those it keeps because they were edited.
+func diffPrune(w *output.Writer, root string, outputs []render.Output) ([]fileAction, error) {
+	if !flags.prune || root == "" {
+		return nil, nil
+	}
+	prev, err := loadManifest(root)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := previewPrune(w, root, prev, outputs)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range actions {
+		if a.Action != "prune" {
+			continue
+		}
+		existing, err := os.ReadFile(a.Path)
+		if err != nil {
+			return nil, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to read existing file: %v", err),
+				err:  err,
+			}
+		}
+		actions[i] = fileAction{Path: a.Path, Action: diffDelete, Diff: diff.Unified(a.Path, "/dev/null", existing, nil)}
+	}
+	return actions, nil
+}
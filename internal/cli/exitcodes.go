@@ -23,4 +23,7 @@ const (
 
 	// ExitSafetyViolation indicates a security issue (path traversal, symlinks).
 	ExitSafetyViolation = 6
+
+	// ExitCheckFailed indicates --check found generated files that are out of date.
+	ExitCheckFailed = 7
 )
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/engine"
+	"github.com/wernerstrydom/render/internal/render"
+)
+
+// outputSource names the dynamic --output template in errors and reports.
+const outputSource = "--output"
+
+// failure is one error collected in --keep-going mode.
+type failure struct {
+	source string // Template source the error belongs to
+	err    error
+}
+
+// failures accumulates errors in --keep-going mode so they can be reported
+// together, grouped by the template source that produced them.
+type failures []failure
+
+// add records an error against the template source it belongs to.
+func (f *failures) add(source string, err error) {
+	*f = append(*f, failure{source: source, err: err})
+}
+
+// addEach records every error in err (a render.Errors list or a single
+// error), attributing each to its source. wrap converts each error into the
+// error to report, e.g. adding the failing item.
+func (f *failures) addEach(err error, fallback string, wrap func(error) error) {
+	var list render.Errors
+	if !errors.As(err, &list) {
+		list = render.Errors{err}
+	}
+	for _, e := range list {
+		f.add(failureSource(e, fallback), wrap(e))
+	}
+}
+
+// err returns the combined exit error, or nil if nothing failed.
+func (f failures) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	sources := make(map[string]bool)
+	for _, fl := range f {
+		sources[fl.source] = true
+	}
+
+	return &exitError{
+		code:     ExitRuntimeError,
+		msg:      fmt.Sprintf("rendering failed with %d error(s) in %d template(s)", len(f), len(sources)),
+		failures: f,
+	}
+}
+
+// format lists the failures grouped by source, in order of first appearance.
+func (f failures) format() string {
+	var order []string
+	groups := make(map[string][]error)
+	for _, fl := range f {
+		if _, ok := groups[fl.source]; !ok {
+			order = append(order, fl.source)
+		}
+		groups[fl.source] = append(groups[fl.source], fl.err)
+	}
+
+	var sb strings.Builder
+	for _, source := range order {
+		fmt.Fprintf(&sb, "%s:\n", source)
+		for _, err := range groups[source] {
+			lines := strings.Split(err.Error(), "\n")
+			fmt.Fprintf(&sb, "  - %s\n", lines[0])
+			for _, line := range lines[1:] {
+				fmt.Fprintf(&sb, "    %s\n", line)
+			}
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// failureSource names the template an error belongs to: the source path
+// recorded by render, else the failing template from an engine error, else
+// fallback.
+func failureSource(err error, fallback string) string {
+	var srcErr *render.SourceError
+	if errors.As(err, &srcErr) {
+		return srcErr.SourcePath
+	}
+	var tmplErr *engine.Error
+	if errors.As(err, &tmplErr) {
+		return tmplErr.Template
+	}
+	return fallback
+}
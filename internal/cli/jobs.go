@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// resolveJobs converts the --jobs flag into a worker count.
+// Zero means one worker per CPU.
+func resolveJobs(jobs int) (int, error) {
+	if jobs < 0 {
+		return 0, &exitError{
+			code: ExitUsageError,
+			msg:  "--jobs must be 0 (one per CPU) or a positive number",
+		}
+	}
+	if jobs == 0 {
+		return runtime.NumCPU(), nil
+	}
+	return jobs, nil
+}
+
+// runItems calls fn for every index in [0, n) using up to jobs goroutines
+// and returns the per-index errors.
+//
+// fn must only write to state owned by its index. Callers inspect results in
+// index order afterwards, so what is reported never depends on scheduling.
+// Once an item fails, items after it are skipped unless keepGoing is set;
+// every item before the lowest failing index is always run, so walking the
+// results in order reaches that failure exactly as a sequential loop would.
+func runItems(n, jobs int, keepGoing bool, fn func(i int) error) []error {
+	errs := make([]error, n)
+
+	var failed atomic.Int64 // lowest failing index seen so far
+	failed.Store(int64(n))
+
+	run := func(i int) {
+		if !keepGoing && int64(i) > failed.Load() {
+			return
+		}
+		if err := fn(i); err != nil {
+			errs[i] = err
+			for {
+				cur := failed.Load()
+				if int64(i) >= cur || failed.CompareAndSwap(cur, int64(i)) {
+					break
+				}
+			}
+		}
+	}
+
+	if jobs <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			run(i)
+		}
+		return errs
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(jobs, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				run(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/manifest"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/render"
+)
+
+// eachOutputRoot returns the directory holding the outputs of a dynamic
+// --output path: the directory part of the text before the first action.
+// The manifest for each modes is kept there. Outputs an action places
+// outside it, such as with an absolute path from the data, aren't recorded.
+func eachOutputRoot() string {
+	prefix, _, _ := strings.Cut(flags.output, "{{")
+	return filepath.Dir(prefix)
+}
+
+// wantManifest reports whether to write the manifest for root, given prev,
+// the manifest of the previous render. An each-mode -o without a directory
+// part, such as '{{.name}}.txt', would keep it in the working directory, so
+// it is written there only with --prune or if an earlier render left one.
+func wantManifest(root string, prev *manifest.Manifest) bool {
+	implicit := root == "." && strings.Contains(flags.output, "{{")
+	return !implicit || flags.prune || len(prev.Files) > 0
+}
+
+// loadManifest loads the manifest of the previous render into root.
+func loadManifest(root string) (*manifest.Manifest, error) {
+	m, err := manifest.Load(root)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitInputValidation,
+			msg:  fmt.Sprintf("failed to load manifest: %v", err),
+			err:  err,
+		}
+	}
+	return m, nil
+}
+
+// newManifest records every planned output relative to root, hashing the
+// content as written, with protected regions merged in.
+func newManifest(w *output.Writer, root string, outputs []render.Output) (*manifest.Manifest, error) {
+	b, err := newManifestBuilder(w, root)
+	if err != nil {
+		return nil, err
+	}
+	for _, out := range outputs {
+		if err := b.add(out); err != nil {
+			return nil, err
+		}
+	}
+	return b.m, nil
+}
+
+// manifestBuilder records outputs in a manifest one at a time, so outputs
+// don't have to be kept until the manifest is written.
+type manifestBuilder struct {
+	w       *output.Writer
+	rootAbs string
+	m       *manifest.Manifest
+}
+
+// newManifestBuilder creates a builder for a manifest kept in root.
+func newManifestBuilder(w *output.Writer, root string) (*manifestBuilder, error) {
+	rootAbs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to resolve output directory: %v", err),
+			err:  err,
+		}
+	}
+	return &manifestBuilder{w: w, rootAbs: rootAbs, m: manifest.New()}, nil
+}
+
+// add records an output relative to the root, hashing the content as
+// written, with protected regions merged in. Outputs outside the root are
+// left out, since the manifest can only list paths prune may delete.
+func (b *manifestBuilder) add(out render.Output) error {
+	outAbs, err := filepath.Abs(out.OutputPath)
+	if err != nil {
+		return &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to resolve output path: %v", err),
+			err:  err,
+		}
+	}
+	relPath, err := filepath.Rel(b.rootAbs, outAbs)
+	if err != nil {
+		return &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to get relative path: %v", err),
+			err:  err,
+		}
+	}
+	if !filepath.IsLocal(relPath) {
+		return nil
+	}
+	content, err := writtenContent(b.w, out)
+	if err != nil {
+		return err
+	}
+	b.m.Add(relPath, content)
+	return nil
+}
+
+// updateManifest writes the manifest for the outputs just written to root.
+// With --prune, files from the previous manifest that are no longer
+// generated are deleted first, through w so the deletions are part of the
+// transaction, and reported as actions; otherwise they stay in the manifest
+// so a later --prune can still remove them. Call it before commitWrites.
+func updateManifest(w *output.Writer, root string, prev *manifest.Manifest, outputs []render.Output) ([]fileAction, error) {
+	next, err := newManifest(w, root, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return saveManifest(w, root, prev, next)
+}
+
+// saveManifest writes next to root, pruning or retaining the files of prev
+// that are no longer generated as updateManifest describes.
+func saveManifest(w *output.Writer, root string, prev, next *manifest.Manifest) ([]fileAction, error) {
+	var actions []fileAction
+	if flags.prune {
+		result, err := manifest.Prune(root, prev, next, w)
+		if result != nil {
+			actions = pruneActions(root, result, "pruned", "kept (modified since generated)")
+		}
+		if err != nil {
+			return actions, wrapWriteError(err, "")
+		}
+	} else if err := manifest.Retain(root, prev, next); err != nil {
+		return nil, wrapWriteError(err, "")
+	}
+
+	// Don't leave a manifest behind for a render that produced nothing
+	if len(next.Files) == 0 && len(prev.Files) == 0 || !wantManifest(root, prev) {
+		return actions, nil
+	}
+
+	if err := next.Save(root); err != nil {
+		return actions, wrapWriteError(err, "")
+	}
+	return actions, nil
+}
+
+// saveWritten writes next, the manifest of the outputs written before a
+// render failed with err, to root, keeping the entries of prev still on
+// disk. Nothing is pruned, since the render didn't produce everything.
+// Returns err, noting if the manifest couldn't be written.
+func saveWritten(root string, prev, next *manifest.Manifest, err error) error {
+	saveErr := manifest.Retain(root, prev, next)
+	if saveErr == nil && len(next.Files) > 0 && wantManifest(root, prev) {
+		saveErr = next.Save(root)
+	}
+	return manifestFailure(err, saveErr)
+}
+
+// abortWrites ends a render whose writes failed with err once written were
+// written: with --transactional they are rolled back, otherwise they are
+// recorded in the manifest in root as saveWritten describes.
+func abortWrites(w *output.Writer, root string, prev *manifest.Manifest, written []render.Output, err error) error {
+	if flags.transactional {
+		return rollbackWrites(w, err)
+	}
+	next, buildErr := newManifest(w, root, written)
+	if buildErr != nil {
+		return manifestFailure(err, buildErr)
+	}
+	return saveWritten(root, prev, next, err)
+}
+
+// manifestFailure returns err, wrapped with saveErr, if any, as the reason
+// the manifest couldn't be written.
+func manifestFailure(err, saveErr error) error {
+	if saveErr == nil {
+		return err
+	}
+	saveErr = fmt.Errorf("failed to save manifest: %w", saveErr)
+	var ee *exitError
+	if errors.As(err, &ee) {
+		ee.msg = fmt.Sprintf("%s (%v)", ee.msg, saveErr)
+		ee.err = errors.Join(ee.err, saveErr)
+		return err
+	}
+	return errors.Join(err, saveErr)
+}
+
+// previewPrune returns the dry-run actions for --prune.
+func previewPrune(w *output.Writer, root string, prev *manifest.Manifest, outputs []render.Output) ([]fileAction, error) {
+	if !flags.prune {
+		return nil, nil
+	}
+	next, err := newManifest(w, root, outputs)
+	if err != nil {
+		return nil, err
+	}
+	return previewManifestPrune(root, prev, next)
+}
+
+// previewManifestPrune returns the dry-run actions for --prune when next
+// replaces prev.
+func previewManifestPrune(root string, prev, next *manifest.Manifest) ([]fileAction, error) {
+	if !flags.prune {
+		return nil, nil
+	}
+	result, err := manifest.Prune(root, prev, next, nil)
+	if err != nil {
+		return nil, wrapWriteError(err, "")
+	}
+	return pruneActions(root, result, "prune", "keep (modified since generated)"), nil
+}
+
+// pruneActions converts a prune result into file actions.
+func pruneActions(root string, result *manifest.PruneResult, removed, modified string) []fileAction {
+	var actions []fileAction
+	for _, path := range result.Removed {
+		actions = append(actions, fileAction{Path: filepath.Join(root, filepath.FromSlash(path)), Action: removed})
+	}
+	for _, path := range result.Modified {
+		actions = append(actions, fileAction{Path: filepath.Join(root, filepath.FromSlash(path)), Action: modified})
+	}
+	return actions
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/provenance"
+)
+
+var regenerateCmd = &cobra.Command{
+	Use:   "regenerate <output-dir>",
+	Short: "Re-render a directory from its recorded provenance",
+	Long: `Re-render an output directory with the inputs recorded in its
+.render-provenance.json by an earlier render with --provenance: the same
+template source, data sources, --output, the --csv flags, --dir-layout,
+--list-merge, --merge-key, --env-prefix, --env-root, the --set style
+flags, --query, --item-query, --stream, --control, --partials, --schema,
+--strict, --skip-empty and --allow-env.
+Environment variables are read again when regenerating.
+
+Recorded paths are relative to the output directory, so a checked-in
+output tree can be regenerated from any working directory. Before
+rendering, regenerate warns about recorded inputs whose content changed
+and about a different render version. The provenance file is rewritten
+afterwards.`,
+	Example: `  # Record how a directory was generated
+  render ./templates data.yaml -o ./dist --provenance
+
+  # Months later: regenerate it the same way
+  render regenerate ./dist --force
+
+  # Or verify in CI that it is up to date
+  render regenerate ./dist --check`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runRegenerateCmd,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(regenerateCmd)
+
+	f := regenerateCmd.Flags()
+	f.BoolVarP(&flags.force, "force", "f", false, "Overwrite existing files")
+	f.BoolVar(&flags.dryRun, "dry-run", false, "Show what would be written without writing")
+	f.BoolVar(&flags.diff, "diff", false, "With --dry-run, show a unified diff of each change")
+	f.BoolVar(&flags.check, "check", false, "Fail if generated files are out of date, without writing")
+	f.BoolVar(&flags.prune, "prune", false, "Delete previously generated files that are no longer produced")
+	f.BoolVar(&flags.transactional, "transactional", false, "Roll back every write if any write fails")
+	f.BoolVar(&flags.keepGoing, "keep-going", false, "Report every rendering error instead of stopping at the first")
+	f.IntVarP(&flags.jobs, "jobs", "j", 1, "Number of items to render concurrently in each mode (0 = one per CPU)")
+	f.BoolVar(&flags.jsonOut, "json", false, "Machine-readable JSON output")
+}
+
+// recordProvenance writes the provenance file for a completed render into
+// its output directory.
+func recordProvenance(templatePath string, sources []string, mode renderMode) error {
+	root := flags.output
+	if mode != modeDirectory {
+		root = eachOutputRoot()
+	}
+
+	provenanceFailure := func(err error) error {
+		return &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to record provenance: %v", err),
+			err:  err,
+		}
+	}
+
+	rec := provenance.New()
+	rec.RenderVersion = version
+	rec.Output = relativeOutput(mode)
+	if flags.dirLayout != "object" {
+		rec.DirLayout = flags.dirLayout
+	}
+	if flags.listMerge != "replace" {
+		rec.ListMerge = flags.listMerge
+	}
+	if flags.mergeKey != "name" {
+		rec.MergeKey = flags.mergeKey
+	}
+	if c := flags.csv; c.delimiter != "" || c.noHeader || len(c.columns) > 0 || c.infer || len(c.types) > 0 {
+		rec.CSV = &provenance.CSV{
+			Delimiter: c.delimiter,
+			NoHeader:  c.noHeader,
+			Columns:   c.columns,
+			Infer:     c.infer,
+			Types:     c.types,
+		}
+	}
+	rec.EnvPrefix = flags.envPrefix
+	rec.EnvRoot = flags.envRoot
+	rec.Query = flags.query
+	rec.ItemQuery = flags.itemQuery
+	rec.Stream = flags.stream
+	rec.Strict = flags.strict
+	rec.SkipEmpty = flags.skipEmpty
+	rec.AllowEnv = flags.allowEnv
+
+	var err error
+	paths := []*string{&rec.Template, &rec.Control, &rec.Partials, &rec.Schema}
+	for i, path := range []string{templatePath, flags.control, flags.partials, flags.schema} {
+		if path == "" {
+			continue
+		}
+		if *paths[i], err = provenance.Rel(root, path); err != nil {
+			return provenanceFailure(err)
+		}
+	}
+	for _, src := range sources {
+		rel, err := provenance.Rel(root, src)
+		if err != nil {
+			return provenanceFailure(err)
+		}
+		rec.Data = append(rec.Data, rel)
+	}
+	for _, s := range slices.Concat(flags.sets, flags.answers) {
+		value := s.value
+		if s.flag == "set-file" {
+			key, file, _ := strings.Cut(value, "=")
+			rel, err := provenance.Rel(root, file)
+			if err != nil {
+				return provenanceFailure(err)
+			}
+			value = key + "=" + rel
+		}
+		rec.Set = append(rec.Set, provenance.Setting{Flag: s.flag, Value: value})
+	}
+
+	if err := rec.Hash(root); err != nil {
+		return provenanceFailure(err)
+	}
+
+	if err := rec.Save(root); err != nil {
+		return wrapWriteError(err, "")
+	}
+	return nil
+}
+
+// relativeOutput returns --output relative to the output directory the
+// provenance file is written to.
+func relativeOutput(mode renderMode) string {
+	if mode == modeDirectory {
+		return "."
+	}
+	prefix, _, _ := strings.Cut(flags.output, "{{")
+	i := strings.LastIndexAny(prefix, "/"+string(filepath.Separator))
+	return flags.output[i+1:]
+}
+
+// runRegenerateCmd runs the regenerate command.
+// With --json, failures are also reported as a JSON error object.
+func runRegenerateCmd(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+
+	rec, err := provenance.Load(dir)
+	if err != nil {
+		msg := fmt.Sprintf("failed to load provenance: %v", err)
+		if errors.Is(err, fs.ErrNotExist) {
+			msg = fmt.Sprintf("no %s in %s (render it with --provenance first)", provenance.FileName, dir)
+		}
+		err = &exitError{code: ExitInputValidation, msg: msg, err: err}
+		if flags.jsonOut {
+			_ = reportError(cmd, err)
+		}
+		return err
+	}
+
+	warnProvenance(cmd, dir, rec)
+
+	flags.output = dir
+	if rec.Output != "." {
+		// Not filepath.Join: cleaning could alter the path template
+		flags.output = dir + string(filepath.Separator) + rec.Output
+	}
+	if rec.DirLayout != "" {
+		flags.dirLayout = rec.DirLayout
+	}
+	if rec.ListMerge != "" {
+		flags.listMerge = rec.ListMerge
+	}
+	if rec.MergeKey != "" {
+		flags.mergeKey = rec.MergeKey
+	}
+	if c := rec.CSV; c != nil {
+		flags.csv = csvFlags{
+			delimiter: c.Delimiter,
+			noHeader:  c.NoHeader,
+			columns:   c.Columns,
+			infer:     c.Infer,
+			types:     c.Types,
+		}
+	}
+	flags.envPrefix = rec.EnvPrefix
+	flags.envRoot = rec.EnvRoot
+	flags.sets = nil
+	for _, s := range rec.Set {
+		value := s.Value
+		if file := s.File(); file != "" {
+			key, _, _ := strings.Cut(value, "=")
+			value = key + "=" + provenance.Resolve(dir, file)
+		}
+		flags.sets = append(flags.sets, setting{flag: s.Flag, value: value})
+	}
+	flags.query = rec.Query
+	flags.itemQuery = rec.ItemQuery
+	// Comparing needs every output at once, and JSON Lines loads as a list
+	flags.stream = rec.Stream && !flags.check && !flags.diff
+	flags.strict = rec.Strict
+	flags.skipEmpty = rec.SkipEmpty
+	flags.allowEnv = rec.AllowEnv
+	if rec.Control != "" {
+		flags.control = provenance.Resolve(dir, rec.Control)
+	}
+	if rec.Partials != "" {
+		flags.partials = provenance.Resolve(dir, rec.Partials)
+	}
+	if rec.Schema != "" {
+		flags.schema = provenance.Resolve(dir, rec.Schema)
+	}
+	flags.provenance = true
+
+	args = []string{provenance.Resolve(dir, rec.Template)}
+	for _, src := range rec.Data {
+		args = append(args, provenance.Resolve(dir, src))
+	}
+	return runRenderCmd(cmd, args)
+}
+
+// warnProvenance warns on stderr when the recorded inputs or render
+// version differ from the current ones.
+func warnProvenance(cmd *cobra.Command, dir string, rec *provenance.Record) {
+	stderr := cmd.ErrOrStderr()
+	if rec.RenderVersion != version {
+		_, _ = fmt.Fprintf(stderr, "Warning: recorded with render %s, running %s\n", rec.RenderVersion, version)
+	}
+
+	changed, err := rec.Changed(dir)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "Warning: %v\n", err)
+		return
+	}
+	if len(changed) > 0 {
+		_, _ = fmt.Fprintln(stderr, "Warning: inputs changed since the provenance was recorded:")
+		for _, path := range changed {
+			_, _ = fmt.Fprintf(stderr, "  %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/config"
+	"github.com/wernerstrydom/render/internal/data"
+	"github.com/wernerstrydom/render/internal/prompt"
+)
+
+// askQuestions asks on stderr for the values the control file's questions
+// name and the data omits (cfg may be nil), and returns the data with the
+// answers. With --no-input only the defaults are used. The answers are
+// recorded for --provenance as --set-json flags, so they aren't asked again.
+func askQuestions(cmd *cobra.Command, cfg *config.ParsedConfig, d any) (any, error) {
+	questions := cfg.Questions()
+	if len(questions) == 0 {
+		return d, nil
+	}
+
+	d, answers, err := prompt.Ask(questions, d, prompt.Options{
+		In:       cmd.InOrStdin(),
+		Out:      cmd.ErrOrStderr(),
+		NoInput:  flags.noInput,
+		Defaults: cfg.Defaults(),
+	})
+	if err != nil {
+		return nil, questionFailure(err, nil)
+	}
+	for _, a := range answers {
+		value, err := json.Marshal(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record answer to %s: %w", a.Name, err)
+		}
+		flags.answers = append(flags.answers, setting{flag: "set-json", value: a.Name + "=" + string(value)})
+	}
+	return d, nil
+}
+
+// previousAnswers returns d with the answers to the control file's
+// questions (cfg may be nil) that d doesn't give taken from prev, the data
+// of a previous render.
+func previousAnswers(cfg *config.ParsedConfig, d, prev any) (any, error) {
+	for _, q := range cfg.Questions() {
+		if _, found, err := data.Lookup(d, q.Name); err != nil || found {
+			continue
+		}
+		value, found, err := data.Lookup(prev, q.Name)
+		if err != nil || !found {
+			continue
+		}
+		if d, err = data.Set(d, q.Name, value); err != nil {
+			return nil, questionFailure(fmt.Errorf("question %s: %w", q.Name, err), nil)
+		}
+	}
+	return d, nil
+}
+
+// answerItems answers the control file's questions for every each-mode
+// item from their defaults, as with --no-input, reporting the unanswered
+// questions of every item. paths locates the items in the data.
+func answerItems(cfg *config.ParsedConfig, paths *itemPaths, items []any) error {
+	questions := cfg.Questions()
+	if len(questions) == 0 {
+		return nil
+	}
+	var fails failures
+	for i, item := range items {
+		answered, _, err := prompt.Ask(questions, item, prompt.Options{NoInput: true, Defaults: cfg.Defaults()})
+		if err != nil {
+			source, ref := itemSource(paths, i)
+			fails.add(source, questionFailure(err, ref))
+			continue
+		}
+		items[i] = answered
+	}
+	return answerFailure(fails)
+}
+
+// questionFailure converts an error from prompt.Ask, for the item ref
+// names, if any.
+func questionFailure(err error, ref *itemRef) *exitError {
+	msg := err.Error()
+	var unanswered prompt.Unanswered
+	if errors.As(err, &unanswered) {
+		msg += " (supply them in the data or with --set)"
+	}
+	return &exitError{code: ExitInputValidation, msg: msg, err: err, item: ref}
+}
+
+// answerFailure returns the error reporting the items whose questions
+// couldn't be answered, or nil if there are none.
+func answerFailure(fails failures) error {
+	if len(fails) == 0 {
+		return nil
+	}
+	return &exitError{
+		code:     ExitInputValidation,
+		msg:      fmt.Sprintf("failed to answer questions for %d item(s)", len(fails)),
+		failures: fails,
+	}
+}
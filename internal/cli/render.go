@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 	"github.com/wernerstrydom/render/internal/config"
@@ -18,27 +22,80 @@ import (
 
 // renderFlags holds the command-line flags for the render command.
 type renderFlags struct {
-	output    string
-	force     bool
-	dryRun    bool
-	control   string
-	jsonOut   bool
-	query     string
-	itemQuery string
+	output        string
+	force         bool
+	dryRun        bool
+	control       string
+	jsonOut       bool
+	query         string
+	itemQuery     string
+	partials      string
+	jobs          int
+	strict        bool
+	keepGoing     bool
+	check         bool
+	diff          bool
+	prune         bool
+	transactional bool
+	snapshot      bool
+	provenance    bool
+	data          []string  // Data sources from --data, after the positional one
+	dataFormat    string    // Format of standard input
+	listMerge     string    // How lists from later data sources combine
+	mergeKey      string    // Item key for --list-merge merge-by-key
+	sets          []setting // --set style flags in command-line order
+	envPrefix     string    // Prefix of environment variables used as data
+	envRoot       bool      // Merge environment variables into the root instead of .env
+	allowEnv      bool      // Enable the env template function
+	csv           csvFlags  // How CSV and TSV data is parsed
+	stream        bool      // Render JSON Lines items as they are read
+	dirLayout     string    // How the files of a directory data source combine
+	schema        string    // JSON Schema the data must match
+	noInput       bool      // Never ask the template's questions
+	skipEmpty     bool      // Leave out templates that render blank
+	answers       []setting // Answers to the template's questions, recorded like --set-json
 }
 
 var flags renderFlags
 
+// csvFlags holds the flags for CSV and TSV data.
+type csvFlags struct {
+	delimiter string
+	noHeader  bool
+	columns   []string
+	infer     bool
+	types     map[string]string
+}
+
 // renderResult represents the JSON output format.
 type renderResult struct {
-	Status string       `json:"status"`
-	Files  []fileAction `json:"files,omitempty"`
-	Error  string       `json:"error,omitempty"`
+	Status  string         `json:"status"`
+	Files   []fileAction   `json:"files,omitempty"`
+	Error   *errorDetail   `json:"error,omitempty"`
+	Errors  []*errorDetail `json:"errors,omitempty"`  // Every failure with --keep-going
+	Summary *diffSummary   `json:"summary,omitempty"` // File counts with --dry-run --diff
+
+	Defaults  map[string]any `json:"defaults,omitempty"`  // Template directory defaults with --dry-run
+	Conflicts []string       `json:"conflicts,omitempty"` // Files merged with conflict markers by update
+}
+
+// errorDetail is the JSON form of a failed render.
+type errorDetail struct {
+	Message  string `json:"message"`
+	Code     int    `json:"code"`
+	Source   string `json:"source,omitempty"`   // Template source the failure is grouped under
+	Template string `json:"template,omitempty"` // Template that failed, relative to the template source
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Snippet  string `json:"snippet,omitempty"`  // Source lines around the failure
+	Item     *int   `json:"item,omitempty"`     // Index of the failing item in each mode
+	ItemPath string `json:"itemPath,omitempty"` // jq path of the failing item in the data
 }
 
 type fileAction struct {
 	Path   string `json:"path"`
 	Action string `json:"action"`
+	Diff   string `json:"diff,omitempty"` // Unified diff with --dry-run --diff
 }
 
 // renderMode represents the detected rendering mode.
@@ -70,16 +127,40 @@ func (m renderMode) String() string {
 }
 
 // runRenderCmd executes the unified render command.
+// With --json, failures are also reported as a JSON error object.
 func runRenderCmd(cmd *cobra.Command, args []string) error {
-	if len(args) != 2 {
+	err := runRender(cmd, args)
+	var ee *exitError
+	if err != nil && flags.jsonOut && !(errors.As(err, &ee) && ee.reported) {
+		_ = reportError(cmd, err)
+	}
+	return err
+}
+
+// runRender detects the rendering mode and runs it.
+func runRender(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
 		return &exitError{
 			code: ExitUsageError,
-			msg:  "requires exactly 2 arguments: <template-source> <data-source>",
+			msg:  "requires a template source",
 		}
 	}
 
 	templatePath := args[0]
-	dataPath := args[1]
+	sources, err := dataSources(args[1:])
+	if err != nil {
+		return err
+	}
+	if flags.provenance && slices.Contains(sources, data.Stdin) {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--provenance can't record data read from standard input",
+		}
+	}
+	// Standard input holds the data, so there is nothing to read answers from
+	if slices.Contains(sources, data.Stdin) {
+		flags.noInput = true
+	}
 
 	// Validate output flag
 	if flags.output == "" {
@@ -89,61 +170,259 @@ func runRenderCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if flags.diff && !flags.dryRun {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--diff requires --dry-run",
+		}
+	}
+
 	// Check for symlinks in template source
 	if err := checkForSymlinks(templatePath); err != nil {
 		return &exitError{code: ExitSafetyViolation, msg: err.Error()}
 	}
 
-	// Load data
-	d, err := data.Load(dataPath)
+	// Determine template type
+	tmplInfo, err := os.Stat(templatePath)
 	if err != nil {
 		return &exitError{
 			code: ExitInputValidation,
-			msg:  fmt.Sprintf("failed to load data: %v", err),
+			msg:  fmt.Sprintf("failed to access template: %v", err),
+			err:  err,
 		}
 	}
 
-	// Apply --query transformation if specified
-	if flags.query != "" {
-		d, err = data.Query(d, flags.query)
-		if err != nil {
-			return &exitError{
-				code: ExitInputValidation,
-				msg:  fmt.Sprintf("failed to apply query: %v", err),
-			}
+	// Streamed items are read while rendering, not loaded up front
+	if flags.stream {
+		mode := inferMode(tmplInfo.IsDir(), flags.output, nil)
+		if err := checkStream(sources, mode); err != nil {
+			return err
+		}
+		if err := checkSkipEmpty(mode); err != nil {
+			return err
+		}
+		if err := executeStreamMode(cmd, templatePath, sources[0], mode); err != nil {
+			return err
+		}
+		if flags.provenance && !flags.dryRun {
+			return recordProvenance(templatePath, sources, mode)
 		}
+		return nil
 	}
 
-	// Determine template type
-	tmplInfo, err := os.Stat(templatePath)
+	d, err := loadData(cmd, sources)
 	if err != nil {
-		return &exitError{
-			code: ExitInputValidation,
-			msg:  fmt.Sprintf("failed to access template: %v", err),
-		}
+		return err
 	}
 
 	// Determine rendering mode
 	mode := inferMode(tmplInfo.IsDir(), flags.output, d)
 
+	if flags.snapshot && mode != modeDirectory {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--snapshot requires directory mode",
+		}
+	}
+
+	// Single-file modes keep no manifest, so there is nothing to prune
+	if flags.prune && (mode == modeFile || mode == modeFileIntoDir) {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--prune requires directory or each mode",
+		}
+	}
+	if flags.provenance && (mode == modeFile || mode == modeFileIntoDir) {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--provenance requires directory or each mode",
+		}
+	}
+	if err := checkSkipEmpty(mode); err != nil {
+		return err
+	}
+
 	// Execute based on mode
 	switch mode {
 	case modeFile:
-		return executeFileMode(cmd, templatePath, d)
+		err = executeFileMode(cmd, templatePath, d)
 	case modeFileIntoDir:
-		return executeFileIntoDirMode(cmd, templatePath, d)
+		err = executeFileIntoDirMode(cmd, templatePath, d)
 	case modeDirectory:
-		return executeDirectoryMode(cmd, templatePath, d)
+		err = executeDirectoryMode(cmd, templatePath, d)
 	case modeEachFile:
-		return executeEachFileMode(cmd, templatePath, d)
+		err = executeEachFileMode(cmd, templatePath, d)
 	case modeEachDirectory:
-		return executeEachDirectoryMode(cmd, templatePath, d)
+		err = executeEachDirectoryMode(cmd, templatePath, d)
 	default:
-		return &exitError{
+		err = &exitError{
 			code: ExitRuntimeError,
 			msg:  fmt.Sprintf("unknown mode: %v", mode),
 		}
 	}
+	if err != nil {
+		return err
+	}
+
+	if flags.provenance && !flags.dryRun && !flags.check {
+		return recordProvenance(templatePath, sources, mode)
+	}
+	return nil
+}
+
+// dataSources returns the data sources from the arguments followed by
+// --data, checking that there is at least one (or --env-prefix) and stdin
+// is read once.
+func dataSources(args []string) ([]string, error) {
+	sources := append(slices.Clone(args), flags.data...)
+	if len(sources) == 0 && flags.envPrefix == "" {
+		return nil, &exitError{
+			code: ExitUsageError,
+			msg:  "requires a data source: <data-source>, --data or --env-prefix",
+		}
+	}
+	if flags.envRoot && flags.envPrefix == "" {
+		return nil, &exitError{
+			code: ExitUsageError,
+			msg:  "--env-root requires --env-prefix",
+		}
+	}
+
+	stdin := 0
+	for _, src := range sources {
+		if src == data.Stdin {
+			stdin++
+		}
+	}
+	switch {
+	case stdin > 1:
+		return nil, &exitError{
+			code: ExitUsageError,
+			msg:  "standard input (-) can only be used as one data source",
+		}
+	case stdin == 0 && flags.dataFormat != "":
+		return nil, &exitError{
+			code: ExitUsageError,
+			msg:  "--data-format requires - (standard input) as a data source",
+		}
+	}
+	return sources, nil
+}
+
+// loadData loads the data sources, deep-merging them in order followed by
+// the environment variables selected by --env-prefix, applies the --set
+// style flags and then --query.
+func loadData(cmd *cobra.Command, sources []string) (any, error) {
+	strategy, err := data.ParseListStrategy(flags.listMerge)
+	if err != nil {
+		return nil, &exitError{code: ExitUsageError, msg: err.Error()}
+	}
+	opts := data.MergeOptions{Lists: strategy, Key: flags.mergeKey}
+
+	csvOpts, err := csvOptions()
+	if err != nil {
+		return nil, err
+	}
+	layout, err := data.ParseDirLayout(flags.dirLayout)
+	if err != nil {
+		return nil, &exitError{code: ExitUsageError, msg: err.Error()}
+	}
+	loadOpts := []data.Option{data.CSV(csvOpts), data.Dir(layout)}
+
+	var d any
+	for i, src := range sources {
+		var v any
+		if src == data.Stdin {
+			v, err = data.LoadReader(cmd.InOrStdin(), flags.dataFormat, loadOpts...)
+		} else {
+			v, err = data.Load(src, loadOpts...)
+		}
+		if err != nil {
+			return nil, &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to load data from %s: %v", sourceName(src), err),
+				err:  err,
+			}
+		}
+
+		if i == 0 {
+			d = v
+		} else {
+			d = data.Merge(d, v, opts)
+		}
+	}
+
+	if flags.envPrefix != "" {
+		if d, err = mergeEnv(d, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if d, err = applySettings(d, flags.sets); err != nil {
+		return nil, err
+	}
+
+	// Apply --query transformation if specified
+	if flags.query != "" {
+		d, err = data.Query(d, flags.query)
+		if err != nil {
+			return nil, &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to apply query: %v", err),
+				err:  err,
+			}
+		}
+	}
+	return d, nil
+}
+
+// csvOptions returns the CSV options selected by the --csv flags.
+func csvOptions() (data.CSVOptions, error) {
+	opts := data.CSVOptions{
+		NoHeader: flags.csv.noHeader,
+		Columns:  flags.csv.columns,
+		Infer:    flags.csv.infer,
+		Types:    flags.csv.types,
+	}
+
+	switch d := flags.csv.delimiter; {
+	case d == "":
+	case d == `\t` || d == "tab":
+		opts.Delimiter = '\t'
+	case utf8.RuneCountInString(d) == 1:
+		opts.Delimiter, _ = utf8.DecodeRuneInString(d)
+	default:
+		return opts, &exitError{
+			code: ExitUsageError,
+			msg:  fmt.Sprintf("invalid --csv-delimiter %q: expected a single character or tab", d),
+		}
+	}
+	return opts, nil
+}
+
+// mergeEnv merges the environment variables selected by --env-prefix into
+// d, under the env key or with --env-root into the root.
+func mergeEnv(d any, opts data.MergeOptions) (any, error) {
+	if _, ok := d.(map[string]any); !ok && d != nil {
+		return nil, &exitError{
+			code: ExitInputValidation,
+			msg:  "--env-prefix requires the data to be an object",
+		}
+	}
+
+	env := data.FromEnv(os.Environ(), flags.envPrefix)
+	if flags.envRoot {
+		return data.Merge(d, env, opts), nil
+	}
+	return data.Merge(d, map[string]any{"env": env}, opts), nil
+}
+
+// sourceName names a data source in messages.
+func sourceName(src string) string {
+	if src == data.Stdin {
+		return "standard input"
+	}
+	return src
 }
 
 // inferMode determines the rendering mode based on inputs.
@@ -168,9 +447,29 @@ func inferMode(isDir bool, outputPath string, _ any) renderMode {
 	return modeFile
 }
 
+// checkSkipEmpty validates --skip-empty, which applies to the templates of
+// a directory.
+func checkSkipEmpty(mode renderMode) error {
+	if flags.skipEmpty && mode != modeDirectory && mode != modeEachDirectory {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "--skip-empty requires directory or each-directory mode",
+		}
+	}
+	return nil
+}
+
 // executeFileMode renders a single template file to a single output file.
 func executeFileMode(cmd *cobra.Command, templatePath string, d any) error {
-	eng := engine.New()
+	if err := validateData(templatePath, nil, d); err != nil {
+		return err
+	}
+
+	eng, err := newEngine(nil)
+	if err != nil {
+		return err
+	}
+	writer := newWriter(nil)
 
 	// Read template
 	tmplContent, err := os.ReadFile(templatePath)
@@ -178,18 +477,24 @@ func executeFileMode(cmd *cobra.Command, templatePath string, d any) error {
 		return &exitError{
 			code: ExitInputValidation,
 			msg:  fmt.Sprintf("failed to read template: %v", err),
+			err:  err,
 		}
 	}
 
 	// Render template
-	result, err := eng.RenderString(string(tmplContent), d)
+	result, err := renderFile(eng, templatePath, string(tmplContent), d)
 	if err != nil {
 		return &exitError{
 			code: ExitRuntimeError,
 			msg:  fmt.Sprintf("failed to render template: %v", err),
+			err:  err,
 		}
 	}
 
+	if flags.check || flags.diff {
+		return compareOutputs(cmd, writer, []render.Output{{OutputPath: flags.output, Content: []byte(result), Overwrite: true}}, nil, "")
+	}
+
 	// Check for collision
 	collision, err := checkCollision(flags.output, []byte(result))
 	if err != nil {
@@ -198,7 +503,7 @@ func executeFileMode(cmd *cobra.Command, templatePath string, d any) error {
 
 	// Dry run - just report what would happen
 	if flags.dryRun {
-		return reportDryRun(cmd, []fileAction{{Path: flags.output, Action: "create"}})
+		return reportDryRun(cmd, []fileAction{{Path: flags.output, Action: "create"}}, nil)
 	}
 
 	// Skip if content is identical (idempotency)
@@ -206,10 +511,16 @@ func executeFileMode(cmd *cobra.Command, templatePath string, d any) error {
 		return reportSuccess(cmd, []fileAction{{Path: flags.output, Action: "skipped (identical)"}})
 	}
 
+	if err := checkRegions(writer, []render.Output{{OutputPath: flags.output, Content: []byte(result), Overwrite: true}}); err != nil {
+		return err
+	}
+
 	// Write output
-	writer := output.New(flags.force)
 	if err := writer.WriteString(flags.output, result); err != nil {
-		return wrapWriteError(err, flags.output)
+		return rollbackWrites(writer, wrapWriteError(err, flags.output))
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
 	}
 
 	return reportSuccess(cmd, []fileAction{{Path: flags.output, Action: "created"}})
@@ -217,7 +528,15 @@ func executeFileMode(cmd *cobra.Command, templatePath string, d any) error {
 
 // executeFileIntoDirMode renders a template file into a target directory.
 func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) error {
-	eng := engine.New()
+	if err := validateData(templatePath, nil, d); err != nil {
+		return err
+	}
+
+	eng, err := newEngine(nil)
+	if err != nil {
+		return err
+	}
+	writer := newWriter(nil)
 
 	// Read template
 	tmplContent, err := os.ReadFile(templatePath)
@@ -225,15 +544,17 @@ func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) erro
 		return &exitError{
 			code: ExitInputValidation,
 			msg:  fmt.Sprintf("failed to read template: %v", err),
+			err:  err,
 		}
 	}
 
 	// Render template
-	result, err := eng.RenderString(string(tmplContent), d)
+	result, err := renderFile(eng, templatePath, string(tmplContent), d)
 	if err != nil {
 		return &exitError{
 			code: ExitRuntimeError,
 			msg:  fmt.Sprintf("failed to render template: %v", err),
+			err:  err,
 		}
 	}
 
@@ -241,6 +562,10 @@ func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) erro
 	baseName := strings.TrimSuffix(filepath.Base(templatePath), ".tmpl")
 	outputPath := filepath.Join(strings.TrimSuffix(flags.output, "/"), baseName)
 
+	if flags.check || flags.diff {
+		return compareOutputs(cmd, writer, []render.Output{{OutputPath: outputPath, Content: []byte(result), Overwrite: true}}, nil, "")
+	}
+
 	// Check for collision
 	collision, err := checkCollision(outputPath, []byte(result))
 	if err != nil {
@@ -248,7 +573,7 @@ func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) erro
 	}
 
 	if flags.dryRun {
-		return reportDryRun(cmd, []fileAction{{Path: outputPath, Action: "create"}})
+		return reportDryRun(cmd, []fileAction{{Path: outputPath, Action: "create"}}, nil)
 	}
 
 	// Skip if content is identical (idempotency)
@@ -256,10 +581,16 @@ func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) erro
 		return reportSuccess(cmd, []fileAction{{Path: outputPath, Action: "skipped (identical)"}})
 	}
 
+	if err := checkRegions(writer, []render.Output{{OutputPath: outputPath, Content: []byte(result), Overwrite: true}}); err != nil {
+		return err
+	}
+
 	// Write output
-	writer := output.New(flags.force)
 	if err := writer.WriteString(outputPath, result); err != nil {
-		return wrapWriteError(err, outputPath)
+		return rollbackWrites(writer, wrapWriteError(err, outputPath))
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
 	}
 
 	return reportSuccess(cmd, []fileAction{{Path: outputPath, Action: "created"}})
@@ -267,22 +598,23 @@ func executeFileIntoDirMode(cmd *cobra.Command, templatePath string, d any) erro
 
 // executeDirectoryMode renders a directory of templates.
 func executeDirectoryMode(cmd *cobra.Command, templatePath string, d any) error {
-	eng := engine.New()
-
 	// Load render config
-	var cfg *config.ParsedConfig
-	var err error
-	if flags.control != "" {
-		cfg, err = config.LoadFile(flags.control, templatePath)
-	} else {
-		cfg, err = config.Load(templatePath)
+	cfg, err := loadRenderConfig(templatePath)
+	if err != nil {
+		return err
 	}
+	if d, err = askQuestions(cmd, cfg, d); err != nil {
+		return err
+	}
+	if err := validateData(templatePath, cfg, d); err != nil {
+		return err
+	}
+
+	eng, err := newEngine(cfg)
 	if err != nil {
-		return &exitError{
-			code: ExitInputValidation,
-			msg:  fmt.Sprintf("failed to load render config: %v", err),
-		}
+		return err
 	}
+	writer := newWriter(cfg)
 
 	// Check for symlinks in template directory
 	if err := checkDirForSymlinks(templatePath); err != nil {
@@ -296,25 +628,44 @@ func executeDirectoryMode(cmd *cobra.Command, templatePath string, d any) error
 		Data:        d,
 		Config:      cfg,
 		Engine:      eng,
+		KeepGoing:   flags.keepGoing,
+		SkipEmpty:   flags.skipEmpty,
 	})
-	if err != nil {
+	collectFailure := func(err error) error {
 		return &exitError{
 			code: ExitRuntimeError,
 			msg:  fmt.Sprintf("failed to collect outputs: %v", err),
+			err:  err,
+		}
+	}
+	var fails failures
+	if err != nil {
+		// With --keep-going a partial plan comes back with every failure
+		if !flags.keepGoing || plan == nil {
+			return collectFailure(err)
 		}
+		fails.addEach(err, templatePath, collectFailure)
 	}
 
 	// Validate
 	if errs := plan.Validate(); len(errs) > 0 {
-		for _, e := range errs {
-			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", e)
-		}
-		return &exitError{
-			code: ExitRuntimeError,
-			msg:  fmt.Sprintf("validation failed with %d error(s)", len(errs)),
+		if flags.keepGoing {
+			fails.addEach(render.Errors(errs), templatePath, func(e error) error { return e })
+		} else {
+			for _, e := range errs {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", e)
+			}
+			return &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("validation failed with %d error(s)", len(errs)),
+			}
 		}
 	}
 
+	if (flags.check || flags.diff) && fails == nil {
+		return compareOutputs(cmd, writer, plan.Outputs, skippedActions(plan), flags.output)
+	}
+
 	// Check for collisions (skipping identical content and no-overwrite files)
 	for _, out := range plan.Outputs {
 		// Skip collision check for no-overwrite files - they're allowed to exist
@@ -323,10 +674,23 @@ func executeDirectoryMode(cmd *cobra.Command, templatePath string, d any) error
 		}
 		_, err := checkCollision(out.OutputPath, out.Content)
 		if err != nil {
-			return err
+			if !flags.keepGoing {
+				return err
+			}
+			fails.add(filepath.ToSlash(out.SourcePath), err)
 		}
 	}
 
+	// Nothing is written unless every file rendered
+	if err := fails.err(); err != nil {
+		return err
+	}
+
+	prev, err := loadManifest(flags.output)
+	if err != nil {
+		return err
+	}
+
 	if flags.dryRun {
 		actions := make([]fileAction, len(plan.Outputs))
 		for i, out := range plan.Outputs {
@@ -342,13 +706,22 @@ func executeDirectoryMode(cmd *cobra.Command, templatePath string, d any) error
 			}
 			actions[i] = fileAction{Path: out.OutputPath, Action: action}
 		}
-		return reportDryRun(cmd, actions)
+		actions = append(actions, skippedActions(plan)...)
+		pruned, err := previewPrune(writer, flags.output, prev, plan.Outputs)
+		if err != nil {
+			return err
+		}
+		return reportDryRun(cmd, append(actions, pruned...), cfg.Defaults())
+	}
+
+	if err := checkRegions(writer, plan.Outputs); err != nil {
+		return err
 	}
 
 	// Execute
-	result, err := plan.Execute(output.New(flags.force))
+	result, err := plan.Execute(writer)
 	if err != nil {
-		return wrapWriteError(err, "")
+		return abortWrites(writer, flags.output, prev, plan.Outputs[:result.Done], wrapWriteError(err, ""))
 	}
 
 	// Report what was written
@@ -362,13 +735,33 @@ func executeDirectoryMode(cmd *cobra.Command, templatePath string, d any) error
 			actions[i] = fileAction{Path: out.OutputPath, Action: "rendered"}
 		}
 	}
+	actions = append(actions, skippedActions(plan)...)
+
+	pruned, err := updateManifest(writer, flags.output, prev, plan.Outputs)
+	if err != nil {
+		return rollbackWrites(writer, err)
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
+	}
+	actions = append(actions, pruned...)
+
+	if flags.snapshot {
+		if err := saveSnapshot(templatePath, d); err != nil {
+			return err
+		}
+	}
 
 	return reportSuccess(cmd, actions)
 }
 
 // executeEachFileMode renders a template for each item in an array.
 func executeEachFileMode(cmd *cobra.Command, templatePath string, d any) error {
-	eng := engine.New()
+	eng, err := newEngine(nil)
+	if err != nil {
+		return err
+	}
+	writer := newWriter(nil)
 
 	// Read template
 	tmplContent, err := os.ReadFile(templatePath)
@@ -376,6 +769,7 @@ func executeEachFileMode(cmd *cobra.Command, templatePath string, d any) error {
 		return &exitError{
 			code: ExitInputValidation,
 			msg:  fmt.Sprintf("failed to read template: %v", err),
+			err:  err,
 		}
 	}
 
@@ -385,24 +779,50 @@ func executeEachFileMode(cmd *cobra.Command, templatePath string, d any) error {
 		return &exitError{
 			code: ExitInputValidation,
 			msg:  fmt.Sprintf("failed to apply item-query: %v", err),
+			err:  err,
+		}
+	}
+	paths := newItemPaths(d)
+	if err := validateItems(templatePath, nil, paths, items); err != nil {
+		return err
+	}
+
+	// Compile the template and output path once; both are executed per item
+	tmpl, err := eng.Compile(filepath.Base(templatePath), string(tmplContent))
+	if err != nil {
+		return &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to render template: %v", err),
+			err:  err,
 		}
 	}
+	pathTmpl, err := compileOutputPath(eng)
+	if err != nil {
+		return err
+	}
 
 	// Pre-flight: collect all outputs to check for collisions
 	type plannedOutput struct {
 		path    string
 		content string
 	}
-	planned := make([]plannedOutput, 0, len(items))
-	seenPaths := make(map[string]int) // path -> index in items
+	jobs, err := resolveJobs(flags.jobs)
+	if err != nil {
+		return err
+	}
+
+	// Render items concurrently; each worker only writes its own slot
+	planned := make([]plannedOutput, len(items))
+	errs := runItems(len(items), jobs, flags.keepGoing, func(i int) error {
+		item := items[i]
 
-	for i, item := range items {
 		// Render output path
-		outPath, err := eng.RenderString(flags.output, item)
+		outPath, err := pathTmpl.Execute(item)
 		if err != nil {
 			return &exitError{
 				code: ExitRuntimeError,
 				msg:  fmt.Sprintf("failed to render output path: %v", err),
+				err:  err,
 			}
 		}
 		outPath = strings.TrimSpace(outPath)
@@ -412,49 +832,103 @@ func executeEachFileMode(cmd *cobra.Command, templatePath string, d any) error {
 			return &exitError{code: ExitSafetyViolation, msg: err.Error()}
 		}
 
-		// Check for internal collision
-		if prevIdx, exists := seenPaths[outPath]; exists {
+		// Render template
+		result, err := tmpl.Execute(item)
+		if err != nil {
 			return &exitError{
 				code: ExitRuntimeError,
-				msg:  fmt.Sprintf("internal collision: items at index %d and %d both produce path %q", prevIdx, i, outPath),
+				msg:  fmt.Sprintf("failed to render template: %v", err),
+				err:  err,
 			}
 		}
-		seenPaths[outPath] = i
 
-		// Render template
-		result, err := eng.RenderString(string(tmplContent), item)
-		if err != nil {
-			return &exitError{
+		planned[i] = plannedOutput{path: outPath, content: result}
+		return nil
+	})
+
+	// Check results and internal collisions in item order
+	templateName := filepath.Base(templatePath)
+	var fails failures
+	seenPaths := make(map[string]int) // path -> index in items
+	for i, p := range planned {
+		if errs[i] != nil {
+			err := itemFailure(paths, i, errs[i])
+			if !flags.keepGoing {
+				return err
+			}
+			fails.add(failureSource(errs[i], outputSource), err)
+			continue
+		}
+		if prevIdx, exists := seenPaths[p.path]; exists {
+			err := &exitError{
 				code: ExitRuntimeError,
-				msg:  fmt.Sprintf("failed to render template: %v", err),
+				msg:  fmt.Sprintf("internal collision: items at index %d and %d both produce path %q", prevIdx, i, p.path),
 			}
+			if !flags.keepGoing {
+				return err
+			}
+			fails.add(outputSource, err)
+			continue
 		}
+		seenPaths[p.path] = i
+	}
 
-		planned = append(planned, plannedOutput{path: outPath, content: result})
+	outputs := make([]render.Output, len(planned))
+	for i, p := range planned {
+		outputs[i] = render.Output{OutputPath: p.path, Content: []byte(p.content), Overwrite: true}
+	}
+
+	if (flags.check || flags.diff) && fails == nil {
+		return compareOutputs(cmd, writer, outputs, nil, eachOutputRoot())
 	}
 
 	// Check for filesystem collisions and track which files can be skipped
 	skipMap := make(map[int]bool)
 	for i, p := range planned {
+		if errs[i] != nil {
+			continue
+		}
 		collision, err := checkCollision(p.path, []byte(p.content))
 		if err != nil {
-			return err
+			if !flags.keepGoing {
+				return err
+			}
+			fails.add(templateName, err)
+			continue
 		}
 		if collision == collisionIdentical {
 			skipMap[i] = true
 		}
 	}
 
+	// Nothing is written unless every item rendered
+	if err := fails.err(); err != nil {
+		return err
+	}
+
+	root := eachOutputRoot()
+	prev, err := loadManifest(root)
+	if err != nil {
+		return err
+	}
+
 	if flags.dryRun {
 		actions := make([]fileAction, len(planned))
 		for i, p := range planned {
 			actions[i] = fileAction{Path: p.path, Action: "create"}
 		}
-		return reportDryRun(cmd, actions)
+		pruned, err := previewPrune(writer, root, prev, outputs)
+		if err != nil {
+			return err
+		}
+		return reportDryRun(cmd, append(actions, pruned...), nil)
+	}
+
+	if err := checkRegions(writer, outputs); err != nil {
+		return err
 	}
 
 	// Write all outputs (skipping identical content)
-	writer := output.New(flags.force)
 	var actions []fileAction
 	for i, p := range planned {
 		if skipMap[i] {
@@ -462,32 +936,35 @@ func executeEachFileMode(cmd *cobra.Command, templatePath string, d any) error {
 			continue
 		}
 		if err := writer.WriteString(p.path, p.content); err != nil {
-			return wrapWriteError(err, p.path)
+			return abortWrites(writer, root, prev, outputs[:i], wrapWriteError(err, p.path))
 		}
 		actions = append(actions, fileAction{Path: p.path, Action: "created"})
 	}
+	pruned, err := updateManifest(writer, root, prev, outputs)
+	if err != nil {
+		return rollbackWrites(writer, err)
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
+	}
+	actions = append(actions, pruned...)
 
 	return reportSuccess(cmd, actions)
 }
 
 // executeEachDirectoryMode renders a directory template for each item in an array.
 func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) error {
-	eng := engine.New()
-
 	// Load render config
-	var cfg *config.ParsedConfig
-	var err error
-	if flags.control != "" {
-		cfg, err = config.LoadFile(flags.control, templatePath)
-	} else {
-		cfg, err = config.Load(templatePath)
+	cfg, err := loadRenderConfig(templatePath)
+	if err != nil {
+		return err
 	}
+
+	eng, err := newEngine(cfg)
 	if err != nil {
-		return &exitError{
-			code: ExitInputValidation,
-			msg:  fmt.Sprintf("failed to load render config: %v", err),
-		}
+		return err
 	}
+	writer := newWriter(cfg)
 
 	// Check for symlinks
 	if err := checkDirForSymlinks(templatePath); err != nil {
@@ -500,24 +977,45 @@ func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) er
 		return &exitError{
 			code: ExitInputValidation,
 			msg:  fmt.Sprintf("failed to apply item-query: %v", err),
+			err:  err,
 		}
 	}
+	paths := newItemPaths(d)
+	if err := answerItems(cfg, paths, items); err != nil {
+		return err
+	}
+	if err := validateItems(templatePath, cfg, paths, items); err != nil {
+		return err
+	}
+
+	// Compile the output path once; templates are cached by the engine
+	pathTmpl, err := compileOutputPath(eng)
+	if err != nil {
+		return err
+	}
 
 	// Pre-flight: collect all outputs to check for collisions
 	type plannedDir struct {
 		outputDir string
 		plan      *render.Plan
 	}
-	allPlanned := make([]plannedDir, 0, len(items))
-	seenPaths := make(map[string]int) // output path -> item index
+	jobs, err := resolveJobs(flags.jobs)
+	if err != nil {
+		return err
+	}
+
+	// Collect each item's plan concurrently; each worker only writes its own slot
+	allPlanned := make([]plannedDir, len(items))
+	errs := runItems(len(items), jobs, flags.keepGoing, func(i int) error {
+		item := items[i]
 
-	for i, item := range items {
 		// Render output directory path
-		outDir, err := eng.RenderString(flags.output, item)
+		outDir, err := pathTmpl.Execute(item)
 		if err != nil {
 			return &exitError{
 				code: ExitRuntimeError,
 				msg:  fmt.Sprintf("failed to render output path: %v", err),
+				err:  err,
 			}
 		}
 		outDir = strings.TrimSpace(outDir)
@@ -534,38 +1032,96 @@ func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) er
 			Data:        item,
 			Config:      cfg,
 			Engine:      eng,
+			KeepGoing:   flags.keepGoing,
+			SkipEmpty:   flags.skipEmpty,
 		})
 		if err != nil {
-			return &exitError{
+			err = &exitError{
 				code: ExitRuntimeError,
 				msg:  fmt.Sprintf("failed to collect outputs: %v", err),
+				err:  err,
+			}
+			if plan == nil {
+				return err
+			}
+		}
+
+		// Keep the partial plan so later checks still see its outputs
+		allPlanned[i] = plannedDir{outputDir: outDir, plan: plan}
+		return err
+	})
+
+	// Check results and internal collisions in item order
+	var fails failures
+	seenPaths := make(map[string]int) // output path -> item index
+	for i, pd := range allPlanned {
+		if errs[i] != nil {
+			if !flags.keepGoing {
+				return itemFailure(paths, i, errs[i])
+			}
+			var ee *exitError
+			if errors.As(errs[i], &ee) && ee.err != nil {
+				// One entry per failing file of this item
+				fails.addEach(ee.err, outputSource, func(e error) error {
+					return itemFailure(paths, i, &exitError{
+						code: ee.code,
+						msg:  fmt.Sprintf("failed to collect outputs: %v", e),
+						err:  e,
+					})
+				})
+			} else {
+				fails.add(outputSource, itemFailure(paths, i, errs[i]))
+			}
+			if pd.plan == nil {
+				continue
 			}
 		}
 
 		// Check for internal collisions across all items
-		for _, out := range plan.Outputs {
+		for _, out := range pd.plan.Outputs {
 			if prevIdx, exists := seenPaths[out.OutputPath]; exists {
-				return &exitError{
+				err := &exitError{
 					code: ExitRuntimeError,
 					msg:  fmt.Sprintf("internal collision: items at index %d and %d both produce path %q", prevIdx, i, out.OutputPath),
 				}
+				if !flags.keepGoing {
+					return err
+				}
+				fails.add(filepath.ToSlash(out.SourcePath), err)
+				continue
 			}
 			seenPaths[out.OutputPath] = i
 		}
 
 		// Validate within item
-		if errs := plan.Validate(); len(errs) > 0 {
-			return &exitError{
-				code: ExitRuntimeError,
-				msg:  fmt.Sprintf("validation failed: %v", errs[0]),
+		if verrs := pd.plan.Validate(); len(verrs) > 0 {
+			if !flags.keepGoing {
+				return &exitError{
+					code: ExitRuntimeError,
+					msg:  fmt.Sprintf("validation failed: %v", verrs[0]),
+				}
 			}
+			fails.addEach(render.Errors(verrs), templatePath, func(e error) error {
+				return itemFailure(paths, i, &exitError{code: ExitRuntimeError, msg: e.Error()})
+			})
 		}
+	}
 
-		allPlanned = append(allPlanned, plannedDir{outputDir: outDir, plan: plan})
+	if (flags.check || flags.diff) && fails == nil {
+		var outputs []render.Output
+		var skipped []fileAction
+		for _, pd := range allPlanned {
+			outputs = append(outputs, pd.plan.Outputs...)
+			skipped = append(skipped, skippedActions(pd.plan)...)
+		}
+		return compareOutputs(cmd, writer, outputs, skipped, eachOutputRoot())
 	}
 
 	// Check for filesystem collisions (skipping identical content and no-overwrite files)
 	for _, pd := range allPlanned {
+		if pd.plan == nil {
+			continue
+		}
 		for _, out := range pd.plan.Outputs {
 			// Skip collision check for no-overwrite files - they're allowed to exist
 			if !out.Overwrite {
@@ -573,11 +1129,29 @@ func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) er
 			}
 			_, err := checkCollision(out.OutputPath, out.Content)
 			if err != nil {
-				return err
+				if !flags.keepGoing {
+					return err
+				}
+				fails.add(filepath.ToSlash(out.SourcePath), err)
 			}
 		}
 	}
 
+	// Nothing is written unless every item rendered
+	if err := fails.err(); err != nil {
+		return err
+	}
+
+	var outputs []render.Output
+	for _, pd := range allPlanned {
+		outputs = append(outputs, pd.plan.Outputs...)
+	}
+	root := eachOutputRoot()
+	prev, err := loadManifest(root)
+	if err != nil {
+		return err
+	}
+
 	if flags.dryRun {
 		var actions []fileAction
 		for _, pd := range allPlanned {
@@ -594,18 +1168,29 @@ func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) er
 				}
 				actions = append(actions, fileAction{Path: out.OutputPath, Action: action})
 			}
+			actions = append(actions, skippedActions(pd.plan)...)
+		}
+		pruned, err := previewPrune(writer, root, prev, outputs)
+		if err != nil {
+			return err
 		}
-		return reportDryRun(cmd, actions)
+		return reportDryRun(cmd, append(actions, pruned...), cfg.Defaults())
+	}
+
+	if err := checkRegions(writer, outputs); err != nil {
+		return err
 	}
 
 	// Execute all plans
-	writer := output.New(flags.force)
 	var actions []fileAction
+	var written []render.Output
 	for _, pd := range allPlanned {
 		result, err := pd.plan.Execute(writer)
 		if err != nil {
-			return wrapWriteError(err, "")
+			written = append(written, pd.plan.Outputs[:result.Done]...)
+			return abortWrites(writer, root, prev, written, wrapWriteError(err, ""))
 		}
+		written = append(written, pd.plan.Outputs...)
 		for _, out := range pd.plan.Outputs {
 			if result.Skipped[out.OutputPath] {
 				actions = append(actions, fileAction{Path: out.OutputPath, Action: "skipped (exists, no-overwrite)"})
@@ -615,11 +1200,124 @@ func executeEachDirectoryMode(cmd *cobra.Command, templatePath string, d any) er
 				actions = append(actions, fileAction{Path: out.OutputPath, Action: "rendered"})
 			}
 		}
+		actions = append(actions, skippedActions(pd.plan)...)
+	}
+	pruned, err := updateManifest(writer, root, prev, outputs)
+	if err != nil {
+		return rollbackWrites(writer, err)
 	}
+	if err := commitWrites(writer); err != nil {
+		return err
+	}
+	actions = append(actions, pruned...)
 
 	return reportSuccess(cmd, actions)
 }
 
+// engineOptions returns the engine options selected by command-line flags.
+func engineOptions() []engine.Option {
+	return []engine.Option{engine.Strict(flags.strict), engine.AllowEnv(flags.allowEnv)}
+}
+
+// loadRenderConfig loads the control file for a template directory, either
+// from --control or by auto-discovery. Returns nil if there is none.
+func loadRenderConfig(templatePath string) (*config.ParsedConfig, error) {
+	var cfg *config.ParsedConfig
+	var err error
+	if flags.control != "" {
+		cfg, err = config.LoadFile(flags.control, templatePath, engineOptions()...)
+	} else {
+		cfg, err = config.Load(templatePath, engineOptions()...)
+	}
+	if err != nil {
+		return nil, &exitError{
+			code: ExitInputValidation,
+			msg:  fmt.Sprintf("failed to load render config: %v", err),
+			err:  err,
+		}
+	}
+	return cfg, nil
+}
+
+// newEngine creates the template engine, loading shared partials from
+// --partials when set. Strict mode is enabled by --strict or by strict: true
+// in the control file (cfg may be nil).
+func newEngine(cfg *config.ParsedConfig) (*engine.Engine, error) {
+	opts := engineOptions()
+	if cfg.Strict() {
+		opts = append(opts, engine.Strict(true))
+	}
+
+	eng := engine.New(opts...)
+	if flags.partials != "" {
+		if err := eng.LoadPartials(flags.partials); err != nil {
+			return nil, &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to load partials: %v", err),
+				err:  err,
+			}
+		}
+	}
+	return eng, nil
+}
+
+// newWriter creates the output writer, using the protected region markers
+// from the control file (cfg may be nil).
+func newWriter(cfg *config.ParsedConfig) *output.Writer {
+	return output.New(flags.force, writerOptions(cfg)...)
+}
+
+// writerOptions returns the writer options selected by the control file
+// (cfg may be nil) and command-line flags.
+func writerOptions(cfg *config.ParsedConfig) []output.Option {
+	opts := []output.Option{output.Regions(cfg.Regions())}
+	if flags.transactional {
+		opts = append(opts, output.Transactional())
+	}
+	return opts
+}
+
+// checkRegions verifies, before anything is written, that every output
+// keeps the protected regions of the file it replaces.
+func checkRegions(w *output.Writer, outputs []render.Output) error {
+	// Without --force, existing files are never replaced
+	if !flags.force {
+		return nil
+	}
+	for _, out := range outputs {
+		if !out.Overwrite {
+			continue
+		}
+		if _, err := writtenContent(w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderFile compiles and renders a single template file's content,
+// naming the template after the file so errors point at it.
+func renderFile(eng *engine.Engine, templatePath, content string, d any) (string, error) {
+	tmpl, err := eng.Compile(filepath.Base(templatePath), content)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Execute(d)
+}
+
+// compileOutputPath compiles the dynamic --output template for each mode.
+func compileOutputPath(eng *engine.Engine) (*engine.Template, error) {
+	t, err := eng.Compile(outputSource, flags.output)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to render output path: %v", err),
+			err:  err,
+		}
+	}
+	return t, nil
+}
+
 // getIterableItems returns items to iterate over.
 // If --item-query is set, uses the query to extract items.
 // Otherwise, if data is an array, returns the array elements.
@@ -638,6 +1336,60 @@ func getIterableItems(d any) ([]any, error) {
 	return []any{d}, nil
 }
 
+// itemFailure annotates an each-mode error with the failing item's index and
+// its jq path in the data, so the item can be found in large data sets.
+func itemFailure(paths *itemPaths, index int, err error) error {
+	var ee *exitError
+	if !errors.As(err, &ee) {
+		return err
+	}
+
+	path := paths.path(index)
+	where := fmt.Sprintf("item %d", index)
+	if path != "" {
+		where = fmt.Sprintf("item %d (%s)", index, path)
+	}
+
+	return &exitError{
+		code: ee.code,
+		msg:  where + ": " + ee.msg,
+		err:  ee.err,
+		item: &itemRef{index: index, path: path},
+	}
+}
+
+// itemPaths finds the jq paths of the each-mode items taken from the data
+// after --query. The --item-query paths are only worked out on the first
+// failure, and then once for every item.
+type itemPaths struct {
+	d     any
+	once  sync.Once
+	paths []string // Path of each item, from --item-query; nil if it isn't a path expression
+}
+
+// newItemPaths returns the paths of the items taken from d.
+func newItemPaths(d any) *itemPaths {
+	return &itemPaths{d: d}
+}
+
+// path returns the jq path of the item at index, relative to the data
+// after --query. Returns "" if --item-query is not a path expression.
+func (p *itemPaths) path(index int) string {
+	if flags.itemQuery != "" {
+		p.once.Do(func() {
+			p.paths, _ = data.QueryPaths(p.d, flags.itemQuery)
+		})
+		if index >= len(p.paths) {
+			return ""
+		}
+		return p.paths[index]
+	}
+	if _, ok := p.d.([]any); ok {
+		return fmt.Sprintf(".[%d]", index)
+	}
+	return "."
+}
+
 // validateOutputPath checks that an output path is safe.
 func validateOutputPath(path string) error {
 	separator := func(r rune) bool {
@@ -663,31 +1415,48 @@ const (
 // Returns (collisionNone, nil) if file doesn't exist or force is enabled (proceed with write).
 // Returns (_, error) if file exists with different content and force not enabled.
 func checkCollision(path string, content []byte) (collisionResult, error) {
-	if !flags.force {
-		if info, err := os.Stat(path); err == nil {
-			if info.Mode().IsRegular() {
-				// Check if content is identical (idempotency)
-				existing, err := os.ReadFile(path)
-				if err != nil {
-					return collisionNone, &exitError{
-						code: ExitOutputConflict,
-						msg:  fmt.Sprintf("file already exists (use --force to overwrite): %s", path),
-					}
-				}
-				if string(existing) == string(content) {
-					// Content is identical, skip the write
-					return collisionIdentical, nil
-				}
-				return collisionNone, &exitError{
-					code: ExitOutputConflict,
-					msg:  fmt.Sprintf("file already exists (use --force to overwrite): %s", path),
-				}
-			}
+	if flags.force {
+		return collisionNone, nil
+	}
+	switch compareFile(path, content) {
+	case fileIdentical:
+		// Content is identical, skip the write
+		return collisionIdentical, nil
+	case fileDifferent:
+		return collisionNone, &exitError{
+			code: ExitOutputConflict,
+			msg:  fmt.Sprintf("file already exists (use --force to overwrite): %s", path),
 		}
 	}
 	return collisionNone, nil
 }
 
+// fileState describes how an existing path compares to rendered content.
+type fileState int
+
+const (
+	fileMissing    fileState = iota // Nothing exists at the path
+	fileIdentical                   // Regular file with identical content
+	fileDifferent                   // Regular file with different or unreadable content
+	fileNotRegular                  // Something other than a regular file, e.g. a directory
+)
+
+// compareFile compares the file at path with content.
+func compareFile(path string, content []byte) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileMissing
+	}
+	if !info.Mode().IsRegular() {
+		return fileNotRegular
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(existing, content) {
+		return fileDifferent
+	}
+	return fileIdentical
+}
+
 // checkForSymlinks checks if a path is a symlink.
 func checkForSymlinks(path string) error {
 	info, err := os.Lstat(path)
@@ -718,6 +1487,37 @@ func checkDirForSymlinks(dir string) error {
 	})
 }
 
+// rollbackWrites undoes the writes made so far when --transactional is set,
+// so a failed write leaves the output tree as it was. err is the write error;
+// if the rollback fails too, the result wraps both.
+func rollbackWrites(w *output.Writer, err error) error {
+	rbErr := w.Rollback()
+	if rbErr == nil {
+		return err
+	}
+	rbErr = fmt.Errorf("rollback failed: %w", rbErr)
+	var ee *exitError
+	if errors.As(err, &ee) {
+		ee.msg = fmt.Sprintf("%s (%v)", ee.msg, rbErr)
+		ee.err = errors.Join(ee.err, rbErr)
+		return err
+	}
+	return errors.Join(err, rbErr)
+}
+
+// commitWrites ends the transaction after every write succeeded,
+// removing the backups of replaced files.
+func commitWrites(w *output.Writer) error {
+	if err := w.Commit(); err != nil {
+		return &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to remove backups: %v", err),
+			err:  err,
+		}
+	}
+	return nil
+}
+
 // wrapWriteError converts a write error to an appropriate exit error.
 func wrapWriteError(err error, _ string) error {
 	errStr := err.Error()
@@ -739,12 +1539,23 @@ func wrapWriteError(err error, _ string) error {
 	}
 }
 
-// reportDryRun reports what would be done in dry-run mode.
-func reportDryRun(cmd *cobra.Command, actions []fileAction) error {
+// skippedActions reports the template directory entries a plan leaves out.
+func skippedActions(plan *render.Plan) []fileAction {
+	actions := make([]fileAction, len(plan.Skipped))
+	for i, s := range plan.Skipped {
+		actions[i] = fileAction{Path: s.OutputPath, Action: "skipped (" + s.Reason + ")"}
+	}
+	return actions
+}
+
+// reportDryRun reports what would be done in dry-run mode. The JSON form
+// includes the template directory's defaults, if any.
+func reportDryRun(cmd *cobra.Command, actions []fileAction, defaults map[string]any) error {
 	if flags.jsonOut {
 		result := renderResult{
-			Status: "dry-run",
-			Files:  actions,
+			Status:   "dry-run",
+			Files:    actions,
+			Defaults: defaults,
 		}
 		enc := json.NewEncoder(cmd.OutOrStdout())
 		enc.SetIndent("", "  ")
@@ -758,6 +1569,54 @@ func reportDryRun(cmd *cobra.Command, actions []fileAction) error {
 	return nil
 }
 
+// reportError reports a failure as a JSON error object. With --keep-going,
+// every collected failure is listed under "errors".
+func reportError(cmd *cobra.Command, err error) error {
+	result := renderResult{
+		Status: "error",
+		Error:  newErrorDetail(err),
+	}
+
+	var ee *exitError
+	if errors.As(err, &ee) {
+		for _, f := range ee.failures {
+			detail := newErrorDetail(f.err)
+			detail.Source = f.source
+			result.Errors = append(result.Errors, detail)
+		}
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // keep the snippet's "> " marker readable
+	return enc.Encode(result)
+}
+
+// newErrorDetail converts an error into its JSON form.
+func newErrorDetail(err error) *errorDetail {
+	detail := &errorDetail{Message: err.Error(), Code: ExitRuntimeError}
+
+	var ee *exitError
+	if errors.As(err, &ee) {
+		detail.Message = ee.msg
+		detail.Code = ee.code
+		if ee.item != nil {
+			detail.Item = &ee.item.index
+			detail.ItemPath = ee.item.path
+		}
+	}
+
+	var tmplErr *engine.Error
+	if errors.As(err, &tmplErr) {
+		detail.Template = tmplErr.Template
+		detail.Line = tmplErr.Line
+		detail.Column = tmplErr.Column
+		detail.Snippet = tmplErr.Snippet
+	}
+
+	return detail
+}
+
 // reportSuccess reports successful completion.
 func reportSuccess(cmd *cobra.Command, actions []fileAction) error {
 	if flags.jsonOut {
@@ -780,12 +1639,38 @@ func reportSuccess(cmd *cobra.Command, actions []fileAction) error {
 type exitError struct {
 	code int
 	msg  string
+	err  error    // Underlying cause, if any
+	item *itemRef // Failing item in each mode, if any
+
+	failures failures // Every failure with --keep-going, if any
+	reported bool     // Already reported on stdout, so --json adds no error object
+}
+
+// itemRef identifies an item in each mode.
+type itemRef struct {
+	index int
+	path  string // jq path in the data, "" if unknown
 }
 
+// Error returns the message, followed by the template source around the
+// failure when the cause is a template error, or by every collected failure
+// grouped by template source.
 func (e *exitError) Error() string {
+	if len(e.failures) > 0 {
+		return e.msg + "\n" + e.failures.format()
+	}
+	var tmplErr *engine.Error
+	if errors.As(e.err, &tmplErr) && tmplErr.Snippet != "" {
+		return e.msg + "\n" + tmplErr.Snippet
+	}
 	return e.msg
 }
 
+// Unwrap returns the underlying cause.
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 // ExitCode returns the exit code for this error.
 func (e *exitError) ExitCode() int {
 	return e.code
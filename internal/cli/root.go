@@ -5,26 +5,32 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "render <template-source> <data-source> -o <output>",
-	Short: "Generate files from Go templates and JSON/YAML data",
+	Use:   "render <template-source> <data-source>... -o <output>",
+	Short: "Generate files from Go templates and structured data",
 	Long: `NAME
-       render - generate files from Go templates and JSON/YAML data
+       render - generate files from Go templates and structured data
 
 SYNOPSIS
-       render <template-source> <data-source> -o <output> [OPTIONS]
+       render <template-source> <data-source>... -o <output> [OPTIONS]
 
 DESCRIPTION
-       render uses Go text templates to generate output files from JSON or
-       YAML data sources. It automatically detects the rendering mode based
-       on the template type and output path.
+       render uses Go text templates to generate output files from JSON,
+       YAML, TOML, HCL (.tfvars), XML, CSV, TSV or JSON Lines data
+       sources. It automatically detects the rendering mode based on
+       the template type and output path.
 
        Template files with a .tmpl extension are processed as Go templates.
        Other files are copied verbatim (in directory mode). The .tmpl
        extension is stripped from output filenames.
 
+       Several data sources are deep-merged in order, so later files
+       override earlier ones. A data source of "-" reads standard input.
+       A directory combines the data files under it (see --dir-layout).
+
 MODES
        render operates in one of three modes, determined automatically:
 
@@ -55,6 +61,87 @@ OPTIONS
               Overwrite existing files without prompting. By default,
               render refuses to overwrite existing files.
 
+       --data <path>
+              Additional data source, merged after the positional ones.
+              May be repeated. A data source of "-" reads standard input.
+
+       --data-format <json|yaml|toml|hcl|xml|csv|tsv|jsonl>
+              Format of data read from standard input. By default JSON,
+              XML or YAML is detected from the content.
+
+       --dir-layout <object|list>
+              How a directory data source combines the data files under
+              it, recursively. "object" (the default) keys each file's
+              data by its path without the extension, such as "api" or
+              "team/web". "list" lists the data in path order, adding
+              the file's path as "_file" to objects. Files with other
+              extensions and hidden files are skipped.
+
+       --csv-delimiter <char>
+              Field delimiter for CSV and TSV data: a single character or
+              "tab". Default "," for .csv and tab for .tsv.
+
+       --csv-no-header
+              The first row of CSV and TSV data is data, not column
+              names. Columns are named column1, column2, ... unless
+              --csv-columns names them.
+
+       --csv-columns <name,...>
+              Column names for CSV and TSV data, used instead of the
+              header row.
+
+       --csv-infer
+              Convert CSV and TSV cells that look like numbers or
+              booleans. Numbers with leading zeros stay strings.
+
+       --csv-types <column=type,...>
+              Types of CSV and TSV columns: string, int, float or bool.
+              Empty cells in int, float and bool columns are null.
+              Example: --csv-types age=int,active=bool
+
+              CSV and TSV data is a list with one object per row, keyed
+              by column name. Cells are strings unless typed.
+
+       --list-merge <replace|append|merge-by-key>
+              How lists are combined when data sources are merged. Maps
+              are always merged recursively and later scalars win.
+              Default "replace".
+
+       --merge-key <key>
+              Key that identifies list items with --list-merge
+              merge-by-key. Default "name".
+
+       --env-prefix <prefix>
+              Use the environment variables whose names start with prefix
+              as data, merged after the data sources. The prefix is
+              stripped, names are lowercased and "__" nests them, so
+              APP_DB__HOST is available as .env.db.host. Values are
+              strings. Without a data source, the data is just these
+              variables.
+
+       --env-root
+              With --env-prefix, merge the variables into the data root
+              instead of under .env, so APP_DB__HOST overrides .db.host.
+
+       --set <key=value>
+              Set a value in the merged data, typed like a YAML scalar
+              (numbers, booleans and null). Keys are dotted paths with
+              optional list indexes, e.g. services[2].port; a backslash
+              escapes a literal dot. May be repeated.
+
+       --set-string <key=value>
+              Like --set, but the value is always a string.
+
+       --set-json <key=json>
+              Like --set, but the value is parsed as JSON.
+
+       --set-file <key=path>
+              Like --set, with the content of a file as a string.
+
+              The --set flags apply in command-line order after the data
+              sources and environment variables are merged and before
+              --query.
+
        --query <jq-expression>
               Transform the entire data using a jq expression before
               rendering. The transformed data becomes the root object
@@ -67,14 +154,116 @@ OPTIONS
               Enables each mode even without dynamic output path.
               Example: --item-query '.users[] | select(.active)'
 
+       --stream
+              Render the items of a JSON Lines data source (.jsonl,
+              .ndjson or - with --data-format jsonl) as they are read,
+              so neither the data nor the rendered files are held in
+              memory. Requires each mode and a single data source;
+              --item-query applies to each line. Output collisions are
+              still detected. With --keep-going, nothing more is written
+              after the first failure, but earlier items stay written.
+              --json keeps the list of files until the end; without it,
+              each file is reported as it is written.
+
+       --strict
+              Fail when a template references a missing key instead of
+              rendering "<no value>". Applies to templates, partials,
+              control file path mappings and the dynamic -o path.
+              Can also be enabled with "strict: true" in .render.yaml.
+
+       -j, --jobs <n>
+              Number of items rendered concurrently in each mode.
+              Default 1; 0 uses one worker per CPU. Output order,
+              collision detection and reported errors are the same
+              regardless of the number of jobs.
+
+       --keep-going
+              Keep rendering after a failure and report every template,
+              output path and collision error, grouped by source
+              template. Nothing is written if any error occurs.
+
+       --allow-env
+              Enable the env template function, as in {{ env "HOME" }}.
+              By default env fails, so output depends only on the
+              templates and data.
+
        --control <path>
               Explicit path to control file (.render.yaml) for path
               mappings. Disables auto-discovery of control files.
 
+       --schema <path>
+              JSON Schema (JSON or YAML) the data must match, validated
+              after --query, or for each item in each mode, before
+              anything is rendered. Every violation is reported with its
+              JSON pointer, exiting with status 3. By default a template
+              directory's schema is used: "schema:" in its control file.
+
+       --no-input
+              Never ask the questions a template directory's control file
+              declares for values the data omits. Questions with a default
+              take it; the rest are listed as unanswered, exiting with
+              status 3. Questions are only asked in directory mode, on
+              stderr; each mode items always behave as with --no-input.
+
+       --skip-empty
+              Don't write templates that render empty or whitespace-only
+              content; they are reported as "skipped (empty)". Applies in
+              directory and each-directory mode. "skipEmpty:" on a path
+              mapping in the control file overrides it for that file or
+              directory.
+
+       --partials <dir>
+              Directory of shared partial templates. Every .tmpl file in
+              it is parsed before rendering, so {{ define }} blocks can be
+              used from any template with {{ template "name" . }}.
+              In directory mode, the .tmpl files under the template
+              directory's _partials/ are loaded automatically.
+
        --dry-run
               Show what files would be written without writing them.
               Useful for previewing output before committing changes.
 
+       --diff
+              With --dry-run, print a unified diff between each existing
+              file and its rendered content, and a summary of new,
+              changed and unchanged files. With --json, each file
+              includes its diff.
+
+       --prune
+              Delete files generated by a previous run that are no longer
+              produced. Directory and each modes record what they write
+              in .render-manifest.json in the output directory; files
+              edited since they were generated are kept. Combine with
+              --dry-run to preview.
+
+       --transactional
+              Make the whole write all-or-nothing. Files are always
+              replaced atomically; with this flag, if any write fails,
+              files already replaced are restored and files already
+              created are removed, so the output is either fully old or
+              fully new.
+
+       --snapshot
+              In directory mode, keep a copy of the templates and data
+              in .render-snapshot/ in the output directory, so that
+              "render update" can later merge template changes into
+              files that were edited since.
+
+       --provenance
+              Record how the output was produced in .render-provenance.json
+              in the output directory: the template and data sources,
+              --output, the data and template options, hashes of every
+              input file and the render version.
+              "render regenerate <dir>" replays it. Directory and each
+              modes only.
+
+       --check
+              Compare what would be rendered with the files on disk
+              without writing anything. Lists files that would be
+              created or changed, and files in the output directory
+              that are no longer generated (stale). Exits with status 7
+              if anything is out of date. Useful in CI.
+
        --json
               Output results in machine-readable JSON format.
               Each line is a JSON object with file operation details.
@@ -87,6 +276,7 @@ EXIT STATUS
        4      Permission denied - filesystem permission error
        5      Output conflict - file exists and --force not specified
        6      Safety violation - path traversal or symlink attack detected
+       7      Check failed - generated files are out of date (--check)
 
 SEE ALSO
        Documentation: https://github.com/wernerstrydom/render/tree/main/docs
@@ -112,11 +302,38 @@ SEE ALSO
 
   # Machine-readable output for scripting
   render ./templates data.json -o ./dist --json`,
-	Args:         cobra.ExactArgs(2),
+	Args:         cobra.MinimumNArgs(1),
 	RunE:         runRenderCmd,
 	SilenceUsage: true,
 }
 
+// addDataFlags adds the flags that select and combine data sources.
+func addDataFlags(f *pflag.FlagSet) {
+	f.StringArrayVar(&flags.data, "data", nil, "Additional data source, merged over earlier ones (repeatable)")
+	f.StringVar(&flags.dataFormat, "data-format", "", "Format of data read from standard input (json, yaml, toml, hcl, xml, csv, tsv or jsonl)")
+	f.StringVar(&flags.dirLayout, "dir-layout", "object", "How a directory data source combines its files: object (keyed by path) or list")
+	f.StringVar(&flags.listMerge, "list-merge", "replace", "How lists from later data sources combine: replace, append or merge-by-key")
+	f.StringVar(&flags.mergeKey, "merge-key", "name", "Item field matched by --list-merge merge-by-key")
+	f.StringVar(&flags.csv.delimiter, "csv-delimiter", "", "Field delimiter for CSV and TSV data (default , for CSV and tab for TSV)")
+	f.BoolVar(&flags.csv.noHeader, "csv-no-header", false, "CSV and TSV data has no header row")
+	f.StringSliceVar(&flags.csv.columns, "csv-columns", nil, "Column names for CSV and TSV data, instead of the header row")
+	f.BoolVar(&flags.csv.infer, "csv-infer", false, "Convert numbers and booleans in CSV and TSV columns without a type")
+	f.StringToStringVar(&flags.csv.types, "csv-types", nil, "Column types for CSV and TSV data, e.g. age=int,active=bool")
+	f.StringVar(&flags.envPrefix, "env-prefix", "", "Use environment variables starting with prefix as data under .env")
+	f.BoolVar(&flags.envRoot, "env-root", false, "With --env-prefix, merge environment variables into the data root")
+	addSetFlags(f)
+}
+
+// version is the render version, set by SetVersion.
+var version = "dev"
+
+// SetVersion sets the version reported by --version and recorded in
+// provenance files.
+func SetVersion(v string) {
+	version = v
+	rootCmd.Version = v
+}
+
 // Execute runs the root command.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -134,8 +351,24 @@ func init() {
 	rootCmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Show what would be written without writing")
 	rootCmd.Flags().StringVar(&flags.control, "control", "", "Explicit path to control file (no auto-discovery)")
 	rootCmd.Flags().BoolVar(&flags.jsonOut, "json", false, "Machine-readable JSON output")
+	addDataFlags(rootCmd.Flags())
 	rootCmd.Flags().StringVar(&flags.query, "query", "", "jq expression to transform data before rendering")
 	rootCmd.Flags().StringVar(&flags.itemQuery, "item-query", "", "jq expression to extract items for iteration")
+	rootCmd.Flags().StringVar(&flags.schema, "schema", "", "JSON Schema the data (or each item in each mode) must match")
+	rootCmd.Flags().BoolVar(&flags.noInput, "no-input", false, "Never ask the template's questions; use their defaults")
+	rootCmd.Flags().BoolVar(&flags.skipEmpty, "skip-empty", false, "Don't write templates that render empty or whitespace-only")
+	rootCmd.Flags().StringVar(&flags.partials, "partials", "", "Directory of shared partial templates (.tmpl)")
+	rootCmd.Flags().BoolVar(&flags.strict, "strict", false, "Fail on missing keys instead of rendering <no value>")
+	rootCmd.Flags().BoolVar(&flags.allowEnv, "allow-env", false, "Allow templates to read environment variables with env")
+	rootCmd.Flags().BoolVar(&flags.stream, "stream", false, "Render JSON Lines items as they are read, in each mode")
+	rootCmd.Flags().IntVarP(&flags.jobs, "jobs", "j", 1, "Number of items to render concurrently in each mode (0 = one per CPU)")
+	rootCmd.Flags().BoolVar(&flags.diff, "diff", false, "With --dry-run, show a unified diff of each change")
+	rootCmd.Flags().BoolVar(&flags.prune, "prune", false, "Delete previously generated files that are no longer produced")
+	rootCmd.Flags().BoolVar(&flags.check, "check", false, "Fail if generated files are out of date, without writing")
+	rootCmd.Flags().BoolVar(&flags.transactional, "transactional", false, "Roll back every write if any write fails")
+	rootCmd.Flags().BoolVar(&flags.snapshot, "snapshot", false, "Keep the templates and data for a later \"render update\"")
+	rootCmd.Flags().BoolVar(&flags.provenance, "provenance", false, "Record the inputs in the output directory for \"render regenerate\"")
+	rootCmd.Flags().BoolVar(&flags.keepGoing, "keep-going", false, "Report every rendering error instead of stopping at the first")
 
 	if err := rootCmd.MarkFlagRequired("output"); err != nil {
 		panic(err)
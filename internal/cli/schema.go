@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/wernerstrydom/render/internal/config"
+	"github.com/wernerstrydom/render/internal/schema"
+)
+
+// loadSchema loads the JSON Schema for the data: --schema, else schema: in
+// the control file (cfg may be nil). Returns nil if there is none.
+func loadSchema(templatePath string, cfg *config.ParsedConfig) (*schema.Schema, error) {
+	path := flags.schema
+	if path == "" && cfg.Schema() != "" {
+		path = filepath.Join(templatePath, cfg.Schema())
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	s, err := schema.Load(path)
+	if err != nil {
+		return nil, &exitError{code: ExitInputValidation, msg: err.Error(), err: err}
+	}
+	return s, nil
+}
+
+// validateData checks the data, merged over the defaults in cfg, against
+// the schema for the template, if any, reporting every violation.
+func validateData(templatePath string, cfg *config.ParsedConfig, d any) error {
+	s, err := loadSchema(templatePath, cfg)
+	if err != nil || s == nil {
+		return err
+	}
+	var fails failures
+	if err := addViolations(&fails, s.Validate(cfg.ApplyDefaults(d)), "data", nil); err != nil {
+		return err
+	}
+	return schemaFailure(s, fails)
+}
+
+// validateItems checks every each-mode item, merged over the defaults in
+// cfg, against the schema for the template, if any, reporting every
+// violation of every item. paths locates the items in the data.
+func validateItems(templatePath string, cfg *config.ParsedConfig, paths *itemPaths, items []any) error {
+	s, err := loadSchema(templatePath, cfg)
+	if err != nil || s == nil {
+		return err
+	}
+	var fails failures
+	for i, item := range items {
+		err := s.Validate(cfg.ApplyDefaults(item))
+		if err == nil {
+			continue
+		}
+		source, ref := itemSource(paths, i)
+		if err := addViolations(&fails, err, source, ref); err != nil {
+			return err
+		}
+	}
+	return schemaFailure(s, fails)
+}
+
+// itemSource names item i, located by paths, as failures are grouped, and
+// returns its reference.
+func itemSource(paths *itemPaths, i int) (string, *itemRef) {
+	ref := &itemRef{index: i, path: paths.path(i)}
+	if ref.path == "" {
+		return fmt.Sprintf("item %d", i), ref
+	}
+	return fmt.Sprintf("item %d (%s)", i, ref.path), ref
+}
+
+// addViolations records each violation in err, the result of validating
+// against a schema, under source, annotated with the item when ref is set.
+func addViolations(fails *failures, err error, source string, ref *itemRef) error {
+	if err == nil {
+		return nil
+	}
+	var violations schema.Violations
+	if !errors.As(err, &violations) {
+		return &exitError{
+			code: ExitInputValidation,
+			msg:  fmt.Sprintf("failed to validate data: %v", err),
+			err:  err,
+		}
+	}
+	for _, violation := range violations {
+		fails.add(source, &exitError{code: ExitInputValidation, msg: violation.Error(), item: ref})
+	}
+	return nil
+}
+
+// schemaFailure returns the error reporting every violation, or nil if
+// there are none.
+func schemaFailure(s *schema.Schema, fails failures) error {
+	if len(fails) == 0 {
+		return nil
+	}
+	return &exitError{
+		code:     ExitInputValidation,
+		msg:      fmt.Sprintf("data does not match schema %s: %d violation(s)", s.Path(), len(fails)),
+		failures: fails,
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/wernerstrydom/render/internal/data"
+)
+
+// setting is one --set style flag, kept in command-line order.
+type setting struct {
+	flag  string // set, set-string, set-json or set-file
+	value string // key=value
+}
+
+// setValue is a pflag.Value that appends its flag's values to
+// flags.sets, so every --set style flag applies in command-line order.
+type setValue struct {
+	flag string
+}
+
+func (v setValue) String() string { return "" }
+
+func (v setValue) Type() string {
+	if v.flag == "set-file" {
+		return "key=path"
+	}
+	return "key=value"
+}
+
+func (v setValue) Set(s string) error {
+	flags.sets = append(flags.sets, setting{flag: v.flag, value: s})
+	return nil
+}
+
+// addSetFlags adds --set, --set-string, --set-json and --set-file to f.
+func addSetFlags(f *pflag.FlagSet) {
+	f.Var(setValue{"set"}, "set", "Set a data value, typed like YAML (repeatable)")
+	f.Var(setValue{"set-string"}, "set-string", "Set a data value as a string (repeatable)")
+	f.Var(setValue{"set-json"}, "set-json", "Set a data value from JSON (repeatable)")
+	f.Var(setValue{"set-file"}, "set-file", "Set a data value to a file's content (repeatable)")
+}
+
+// applySettings applies the --set style flags to d in order.
+func applySettings(d any, sets []setting) (any, error) {
+	for _, s := range sets {
+		path, raw, ok := strings.Cut(s.value, "=")
+		if !ok {
+			return nil, &exitError{
+				code: ExitUsageError,
+				msg:  fmt.Sprintf("invalid --%s %q: expected key=value", s.flag, s.value),
+			}
+		}
+
+		var value any
+		switch s.flag {
+		case "set":
+			value = data.ParseValue(raw)
+		case "set-string":
+			value = raw
+		case "set-json":
+			v, err := data.Parse([]byte(raw), "json")
+			if err != nil {
+				return nil, &exitError{
+					code: ExitUsageError,
+					msg:  fmt.Sprintf("invalid --set-json %s: %v", path, err),
+					err:  err,
+				}
+			}
+			value = v
+		case "set-file":
+			content, err := os.ReadFile(raw)
+			if err != nil {
+				return nil, &exitError{
+					code: ExitInputValidation,
+					msg:  fmt.Sprintf("failed to read --set-file %s: %v", path, err),
+					err:  err,
+				}
+			}
+			value = string(content)
+		}
+
+		var err error
+		if d, err = data.Set(d, path, value); err != nil {
+			return nil, &exitError{
+				code: ExitUsageError,
+				msg:  fmt.Sprintf("invalid --%s %q: %v", s.flag, s.value, err),
+				err:  err,
+			}
+		}
+	}
+	return d, nil
+}
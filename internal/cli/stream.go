@@ -0,0 +1,603 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/config"
+	"github.com/wernerstrydom/render/internal/data"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/prompt"
+	"github.com/wernerstrydom/render/internal/render"
+	"github.com/wernerstrydom/render/internal/schema"
+)
+
+// checkStream validates --stream: it needs an each mode and a single JSON
+// Lines data source, and can't be combined with flags that need all the
+// data at once.
+func checkStream(sources []string, mode renderMode) error {
+	usage := func(msg string) error {
+		return &exitError{code: ExitUsageError, msg: msg}
+	}
+
+	switch {
+	case mode != modeEachFile && mode != modeEachDirectory:
+		return usage("--stream requires each mode (a dynamic --output)")
+	case len(sources) != 1:
+		return usage("--stream requires exactly one data source")
+	case flags.query != "":
+		return usage("--stream can't be combined with --query; use --item-query")
+	case len(flags.sets) > 0:
+		return usage("--stream can't be combined with --set style flags")
+	case flags.envPrefix != "":
+		return usage("--stream can't be combined with --env-prefix")
+	case flags.check || flags.diff:
+		return usage("--stream can't be combined with --check or --diff")
+	case flags.snapshot:
+		return usage("--snapshot requires directory mode")
+	}
+
+	format := flags.dataFormat
+	if sources[0] != data.Stdin {
+		var err error
+		if format, err = data.DetectFormat(sources[0]); err != nil {
+			return &exitError{code: ExitInputValidation, msg: err.Error(), err: err}
+		}
+	}
+	if format != "" && format != "jsonl" {
+		return usage("--stream requires JSON Lines data (.jsonl or .ndjson)")
+	}
+	return nil
+}
+
+// streamItem is one item read from a JSON Lines data source.
+type streamItem struct {
+	value any
+	line  int // Line of the data source the item came from
+}
+
+// streamReader reads items one line at a time. With --item-query, each line
+// may produce any number of items.
+type streamReader struct {
+	src     string
+	lines   *data.Lines
+	pending []streamItem
+}
+
+// next returns the next item, or io.EOF after the last one.
+func (r *streamReader) next() (streamItem, error) {
+	for len(r.pending) == 0 {
+		v, line, err := r.lines.Next()
+		if errors.Is(err, io.EOF) {
+			return streamItem{}, err
+		}
+		if err != nil {
+			return streamItem{}, &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to load data from %s: %v", sourceName(r.src), err),
+				err:  err,
+			}
+		}
+		if flags.itemQuery == "" {
+			return streamItem{value: v, line: line}, nil
+		}
+
+		items, err := data.QueryAll(v, flags.itemQuery)
+		if err != nil {
+			return streamItem{}, &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to apply item-query: line %d: %v", line, err),
+				err:  err,
+			}
+		}
+		for _, item := range items {
+			r.pending = append(r.pending, streamItem{value: item, line: line})
+		}
+	}
+
+	item := r.pending[0]
+	r.pending = r.pending[1:]
+	return item, nil
+}
+
+// pathIndex remembers which item produced each output path, keyed by a hash
+// of the path so millions of paths stay small in memory.
+type pathIndex map[[16]byte]int
+
+// add records that item produced path, returning the earlier item and true
+// if one already produced it.
+func (x pathIndex) add(path string, item int) (int, bool) {
+	sum := sha256.Sum256([]byte(path))
+	var key [16]byte
+	copy(key[:], sum[:])
+	if prev, ok := x[key]; ok {
+		return prev, true
+	}
+	x[key] = item
+	return 0, false
+}
+
+// streamRun holds the state of a streaming render while items are written.
+type streamRun struct {
+	cmd      *cobra.Command
+	writer   *output.Writer
+	manifest *manifestBuilder
+	paths    pathIndex
+	fails    failures
+	actions  []fileAction // Kept only for --json, which reports once at the end
+
+	eachFile     bool   // Rendering a file template rather than a directory
+	templatePath string // Template source, for validation failures
+}
+
+// executeStreamMode renders each item of a JSON Lines data source as it is
+// read, in batches of --jobs items, writing each batch before reading the
+// next. Neither the data nor the rendered content is held in memory; only
+// a hash of each output path is kept to detect collisions, along with the
+// manifest.
+func executeStreamMode(cmd *cobra.Command, templatePath, src string, mode renderMode) error {
+	var (
+		setup *streamSetup
+		err   error
+	)
+	if mode == modeEachFile {
+		setup, err = streamEachFile(templatePath)
+	} else {
+		setup, err = streamEachDirectory(templatePath)
+	}
+	if err != nil {
+		return err
+	}
+	writer := setup.writer
+
+	jobs, err := resolveJobs(flags.jobs)
+	if err != nil {
+		return err
+	}
+
+	reader := &streamReader{src: src}
+	if src == data.Stdin {
+		reader.lines = data.NewLines(cmd.InOrStdin())
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return &exitError{
+				code: ExitInputValidation,
+				msg:  fmt.Sprintf("failed to load data from %s: %v", src, err),
+				err:  err,
+			}
+		}
+		defer func() { _ = f.Close() }()
+		reader.lines = data.NewLines(f)
+	}
+
+	root := eachOutputRoot()
+	prev, err := loadManifest(root)
+	if err != nil {
+		return err
+	}
+	b, err := newManifestBuilder(writer, root)
+	if err != nil {
+		return err
+	}
+
+	run := &streamRun{
+		cmd:          cmd,
+		writer:       writer,
+		manifest:     b,
+		paths:        make(pathIndex),
+		eachFile:     mode == modeEachFile,
+		templatePath: templatePath,
+	}
+	if flags.dryRun && !flags.jsonOut {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Dry run - would perform:")
+	}
+
+	// Earlier batches are already written when an item fails: roll them
+	// back with --transactional, otherwise record them so they can be pruned
+	abort := func(err error) error {
+		if flags.transactional || flags.dryRun {
+			return rollbackWrites(writer, err)
+		}
+		return saveWritten(root, prev, b.m, err)
+	}
+
+	batch := make([]streamItem, 0, jobs)
+	plans := make([]*render.Plan, jobs)
+	for index, done := 0, false; !done; index += len(batch) {
+		batch = batch[:0]
+		for len(batch) < jobs {
+			item, err := reader.next()
+			if errors.Is(err, io.EOF) {
+				done = true
+				break
+			}
+			if err != nil {
+				return abort(err)
+			}
+			batch = append(batch, item)
+		}
+
+		clear(plans)
+		errs := runItems(len(batch), jobs, flags.keepGoing, func(i int) error {
+			if err := setup.answer(index+i, &batch[i]); err != nil {
+				return err
+			}
+			if err := setup.validate(index+i, batch[i]); err != nil {
+				return err
+			}
+			var err error
+			plans[i], err = setup.render(batch[i].value)
+			return err
+		})
+		for i, item := range batch {
+			if err := run.item(index+i, item.line, plans[i], errs[i]); err != nil {
+				return abort(err)
+			}
+		}
+	}
+
+	if err := run.fails.err(); err != nil {
+		return abort(err)
+	}
+
+	if flags.dryRun {
+		pruned, err := previewManifestPrune(root, prev, b.m)
+		if err != nil {
+			return err
+		}
+		run.report(pruned...)
+		if flags.jsonOut {
+			return reportDryRun(cmd, run.actions, setup.cfg.Defaults())
+		}
+		return nil
+	}
+
+	pruned, err := saveManifest(writer, root, prev, b.m)
+	if err != nil {
+		return rollbackWrites(writer, err)
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
+	}
+	run.report(pruned...)
+	if flags.jsonOut {
+		return reportSuccess(cmd, run.actions)
+	}
+	return nil
+}
+
+// streamSetup is what a mode needs to render streamed items.
+type streamSetup struct {
+	writer *output.Writer
+	schema *schema.Schema                  // Schema each item must match, if any
+	cfg    *config.ParsedConfig            // Control file, for the questions and defaults of items
+	render func(any) (*render.Plan, error) // Renders one item
+}
+
+// answer answers the control file's questions for an item from their
+// defaults, as with --no-input.
+func (s *streamSetup) answer(index int, item *streamItem) error {
+	questions := s.cfg.Questions()
+	if len(questions) == 0 {
+		return nil
+	}
+	answered, _, err := prompt.Ask(questions, item.value, prompt.Options{NoInput: true, Defaults: s.cfg.Defaults()})
+	if err != nil {
+		var fails failures
+		source := fmt.Sprintf("item %d (line %d)", index, item.line)
+		fails.add(source, questionFailure(err, &itemRef{index: index}))
+		return answerFailure(fails)
+	}
+	item.value = answered
+	return nil
+}
+
+// validate checks an item against the schema, if any, reporting every
+// violation.
+func (s *streamSetup) validate(index int, item streamItem) error {
+	if s.schema == nil {
+		return nil
+	}
+	var fails failures
+	source := fmt.Sprintf("item %d (line %d)", index, item.line)
+	if err := addViolations(&fails, s.schema.Validate(s.cfg.ApplyDefaults(item.value)), source, &itemRef{index: index}); err != nil {
+		return err
+	}
+	return schemaFailure(s.schema, fails)
+}
+
+// streamEachFile prepares each-file mode for streaming, rendering each
+// item to a plan with a single output.
+func streamEachFile(templatePath string) (*streamSetup, error) {
+	s, err := loadSchema(templatePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := newEngine(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tmplContent, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, &exitError{
+			code: ExitInputValidation,
+			msg:  fmt.Sprintf("failed to read template: %v", err),
+			err:  err,
+		}
+	}
+	tmpl, err := eng.Compile(filepath.Base(templatePath), string(tmplContent))
+	if err != nil {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to render template: %v", err),
+			err:  err,
+		}
+	}
+	pathTmpl, err := compileOutputPath(eng)
+	if err != nil {
+		return nil, err
+	}
+
+	renderItem := func(item any) (*render.Plan, error) {
+		outPath, err := pathTmpl.Execute(item)
+		if err != nil {
+			return nil, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to render output path: %v", err),
+				err:  err,
+			}
+		}
+		outPath = strings.TrimSpace(outPath)
+		if err := validateOutputPath(outPath); err != nil {
+			return nil, &exitError{code: ExitSafetyViolation, msg: err.Error()}
+		}
+
+		result, err := tmpl.Execute(item)
+		if err != nil {
+			return nil, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to render template: %v", err),
+				err:  err,
+			}
+		}
+		return &render.Plan{Outputs: []render.Output{{
+			SourcePath: filepath.Base(templatePath),
+			OutputPath: outPath,
+			Content:    []byte(result),
+			Overwrite:  true,
+		}}}, nil
+	}
+	return &streamSetup{writer: newWriter(nil), schema: s, render: renderItem}, nil
+}
+
+// streamEachDirectory prepares each-directory mode for streaming,
+// collecting each item's plan.
+func streamEachDirectory(templatePath string) (*streamSetup, error) {
+	cfg, err := loadRenderConfig(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	s, err := loadSchema(templatePath, cfg)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := newEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirForSymlinks(templatePath); err != nil {
+		return nil, &exitError{code: ExitSafetyViolation, msg: err.Error()}
+	}
+	pathTmpl, err := compileOutputPath(eng)
+	if err != nil {
+		return nil, err
+	}
+
+	renderItem := func(item any) (*render.Plan, error) {
+		outDir, err := pathTmpl.Execute(item)
+		if err != nil {
+			return nil, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to render output path: %v", err),
+				err:  err,
+			}
+		}
+		outDir = strings.TrimSpace(outDir)
+		if err := validateOutputPath(outDir); err != nil {
+			return nil, &exitError{code: ExitSafetyViolation, msg: err.Error()}
+		}
+
+		plan, err := render.Collect(render.CollectConfig{
+			TemplateDir: templatePath,
+			OutputDir:   outDir,
+			Data:        item,
+			Config:      cfg,
+			Engine:      eng,
+			KeepGoing:   flags.keepGoing,
+			SkipEmpty:   flags.skipEmpty,
+		})
+		if err != nil {
+			return plan, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to collect outputs: %v", err),
+				err:  err,
+			}
+		}
+		return plan, nil
+	}
+	return &streamSetup{writer: newWriter(cfg), schema: s, cfg: cfg, render: renderItem}, nil
+}
+
+// item checks and writes the plan of one item. With --keep-going, failures
+// are collected and nothing more is written once an item has failed;
+// otherwise the first failure is returned.
+func (r *streamRun) item(index, line int, plan *render.Plan, err error) error {
+	fail := func(source string, err error) error {
+		if !flags.keepGoing {
+			return err
+		}
+		r.fails.add(source, err)
+		return nil
+	}
+
+	if err != nil {
+		var ee *exitError
+		if flags.keepGoing && errors.As(err, &ee) && ee.failures != nil {
+			// Schema violations, each already naming the item
+			r.fails = append(r.fails, ee.failures...)
+			return nil
+		}
+		if flags.keepGoing && !r.eachFile && errors.As(err, &ee) && ee.err != nil {
+			// One entry per failing file of this item
+			r.fails.addEach(ee.err, outputSource, func(e error) error {
+				return streamItemFailure(index, line, &exitError{
+					code: ee.code,
+					msg:  fmt.Sprintf("failed to collect outputs: %v", e),
+					err:  e,
+				})
+			})
+			return nil
+		}
+		return fail(failureSource(err, outputSource), streamItemFailure(index, line, err))
+	}
+
+	for _, out := range plan.Outputs {
+		if prev, exists := r.paths.add(out.OutputPath, index); exists {
+			err := &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("internal collision: items at index %d and %d both produce path %q", prev, index, out.OutputPath),
+			}
+			if err := fail(outputSource, err); err != nil {
+				return err
+			}
+		}
+	}
+	if verrs := plan.Validate(); len(verrs) > 0 {
+		err := streamItemFailure(index, line, &exitError{code: ExitRuntimeError, msg: fmt.Sprintf("validation failed: %v", verrs[0])})
+		if err := fail(r.templatePath, err); err != nil {
+			return err
+		}
+	}
+
+	// Identical files are left alone in each-file mode
+	skip := make(map[string]bool)
+	for _, out := range plan.Outputs {
+		if !out.Overwrite {
+			continue
+		}
+		collision, err := checkCollision(out.OutputPath, out.Content)
+		if err != nil {
+			if err := fail(filepath.ToSlash(out.SourcePath), err); err != nil {
+				return err
+			}
+			continue
+		}
+		if collision == collisionIdentical && r.eachFile {
+			skip[out.OutputPath] = true
+		}
+	}
+	if r.fails != nil {
+		return nil
+	}
+
+	if flags.dryRun {
+		for _, out := range plan.Outputs {
+			if err := r.manifest.add(out); err != nil {
+				return err
+			}
+			r.report(fileAction{Path: out.OutputPath, Action: r.plannedAction(out)})
+		}
+		r.report(skippedActions(plan)...)
+		return nil
+	}
+
+	if err := checkRegions(r.writer, plan.Outputs); err != nil {
+		return err
+	}
+	var write render.Plan
+	for _, out := range plan.Outputs {
+		if !skip[out.OutputPath] {
+			write.Outputs = append(write.Outputs, out)
+		}
+	}
+	result, err := write.Execute(r.writer)
+	if err != nil {
+		return wrapWriteError(err, "")
+	}
+
+	for _, out := range plan.Outputs {
+		if err := r.manifest.add(out); err != nil {
+			return err
+		}
+		action := "rendered"
+		switch {
+		case skip[out.OutputPath]:
+			action = "skipped (identical)"
+		case result.Skipped[out.OutputPath]:
+			action = "skipped (exists, no-overwrite)"
+		case out.CopyFrom != "":
+			action = "copied"
+		case r.eachFile:
+			action = "created"
+		}
+		r.report(fileAction{Path: out.OutputPath, Action: action})
+	}
+	r.report(skippedActions(plan)...)
+	return nil
+}
+
+// plannedAction returns the dry-run action for an output.
+func (r *streamRun) plannedAction(out render.Output) string {
+	if r.eachFile {
+		return "create"
+	}
+	if out.CopyFrom != "" {
+		return "copy"
+	}
+	if !out.Overwrite {
+		if _, err := os.Stat(out.OutputPath); err == nil {
+			return "skip (exists, no-overwrite)"
+		}
+	}
+	return "render"
+}
+
+// report prints actions as they happen, or keeps them for --json.
+func (r *streamRun) report(actions ...fileAction) {
+	if flags.jsonOut {
+		r.actions = append(r.actions, actions...)
+		return
+	}
+	out := r.cmd.OutOrStdout()
+	for _, a := range actions {
+		if flags.dryRun {
+			_, _ = fmt.Fprintf(out, "  [%s] %s\n", a.Action, a.Path)
+		} else {
+			_, _ = fmt.Fprintf(out, "%s: %s\n", capitalizeFirst(a.Action), a.Path)
+		}
+	}
+}
+
+// streamItemFailure annotates an error with the failing item's index and
+// the line of the data source it came from.
+func streamItemFailure(index, line int, err error) error {
+	var ee *exitError
+	if !errors.As(err, &ee) || ee.failures != nil {
+		// Schema violations already name the item
+		return err
+	}
+	return &exitError{
+		code: ee.code,
+		msg:  fmt.Sprintf("item %d (line %d): %s", index, line, ee.msg),
+		err:  ee.err,
+		item: &itemRef{index: index},
+	}
+}
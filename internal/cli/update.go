@@ -0,0 +1,449 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wernerstrydom/render/internal/config"
+	"github.com/wernerstrydom/render/internal/data"
+	"github.com/wernerstrydom/render/internal/diff"
+	"github.com/wernerstrydom/render/internal/engine"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/render"
+	"github.com/wernerstrydom/render/internal/snapshot"
+)
+
+// Update actions, reported per file.
+const (
+	updateCreate    = "create"
+	updateUpdate    = "update"    // Unedited file replaced by the new render
+	updateMerge     = "merge"     // Template changes merged into local edits
+	updateConflict  = "conflict"  // Merged with conflict markers
+	updateUnchanged = "unchanged" // Nothing to change
+	updateDeleted   = "skip (deleted locally)"
+	updateNoWrite   = "skip (exists, no-overwrite)"
+	updateRemoved   = "keep (removed from template)"
+)
+
+// Conflict marker labels for the two sides of a merge.
+const (
+	labelCurrent  = "current"
+	labelTemplate = "template"
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update <template-dir> <data-source>... -o <output-dir>",
+	Short: "Merge template changes into a previously generated directory",
+	Long: `Re-render a directory generated with --snapshot and merge the changes
+into the files on disk, keeping local edits.
+
+The snapshot in the output directory holds the templates and data the
+directory was last rendered from. update renders that previous version and
+the new one, and performs a three-way merge into each current file:
+
+  - template changes to files nobody edited are applied
+  - local edits to files the template didn't change are kept
+  - both are combined where they touch different lines
+  - where they touch the same lines, the file gets conflict markers:
+
+      <<<<<<< current
+      local version
+      =======
+      template version
+      >>>>>>> template
+
+Files that are new in the template are created; files removed from the
+template are kept. Questions answered for the previous render aren't
+asked again. The snapshot is then replaced with the new templates
+and data. If any file has conflicts, update exits with status 5 after
+writing, and --json lists the files under "conflicts".`,
+	Example: `  # Generate a project, keeping a snapshot for later updates
+  render ./scaffold service.yaml -o ./my-service --snapshot
+
+  # After improving the templates, merge the changes into the project
+  render update ./scaffold service.yaml -o ./my-service
+
+  # Preview the merge
+  render update ./scaffold service.yaml -o ./my-service --dry-run`,
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         runUpdateCmd,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	f := updateCmd.Flags()
+	f.StringVarP(&flags.output, "output", "o", "", "Previously generated output directory (required)")
+	addDataFlags(f)
+	f.StringVar(&flags.query, "query", "", "jq expression to transform data before rendering")
+	f.StringVar(&flags.control, "control", "", "Explicit path to control file (no auto-discovery)")
+	f.StringVar(&flags.schema, "schema", "", "JSON Schema the data must match")
+	f.BoolVar(&flags.noInput, "no-input", false, "Never ask the template's new questions; use their defaults")
+	f.StringVar(&flags.partials, "partials", "", "Directory of shared partial templates (.tmpl)")
+	f.BoolVar(&flags.skipEmpty, "skip-empty", false, "Don't write templates that render blank (default: as in the previous render)")
+	f.BoolVar(&flags.strict, "strict", false, "Fail on missing keys instead of rendering <no value>")
+	f.BoolVar(&flags.allowEnv, "allow-env", false, "Allow templates to read environment variables with env")
+	f.BoolVar(&flags.dryRun, "dry-run", false, "Show what would be merged without writing")
+	f.BoolVar(&flags.transactional, "transactional", false, "Roll back every write if any write fails")
+	f.BoolVar(&flags.jsonOut, "json", false, "Machine-readable JSON output")
+
+	if err := updateCmd.MarkFlagRequired("output"); err != nil {
+		panic(err)
+	}
+}
+
+// runUpdateCmd runs the update command.
+// With --json, failures are also reported as a JSON error object.
+func runUpdateCmd(cmd *cobra.Command, args []string) error {
+	err := runUpdate(cmd, args)
+	var ee *exitError
+	if err != nil && flags.jsonOut && !(errors.As(err, &ee) && ee.reported) {
+		_ = reportError(cmd, err)
+	}
+	return err
+}
+
+// runUpdate merges the new render of a template directory into the output
+// directory, using the snapshot of the previous render as the base.
+func runUpdate(cmd *cobra.Command, args []string) error {
+	templatePath := args[0]
+	sources, err := dataSources(args[1:])
+	if err != nil {
+		return err
+	}
+	// Standard input holds the data, so there is nothing to read answers from
+	if slices.Contains(sources, data.Stdin) {
+		flags.noInput = true
+	}
+
+	if strings.Contains(flags.output, "{{") {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  "update requires a static output directory",
+		}
+	}
+	if info, err := os.Stat(templatePath); err != nil || !info.IsDir() {
+		return &exitError{
+			code: ExitUsageError,
+			msg:  fmt.Sprintf("update requires a template directory: %s", templatePath),
+		}
+	}
+	if err := checkDirForSymlinks(templatePath); err != nil {
+		return &exitError{code: ExitSafetyViolation, msg: err.Error()}
+	}
+
+	snap, err := snapshot.Load(flags.output)
+	if err != nil {
+		msg := fmt.Sprintf("failed to load snapshot: %v", err)
+		if errors.Is(err, fs.ErrNotExist) {
+			msg = fmt.Sprintf("no snapshot in %s (render it with --snapshot first)", flags.output)
+		}
+		return &exitError{code: ExitInputValidation, msg: msg, err: err}
+	}
+
+	prevData, err := snap.Data()
+	if err != nil {
+		return snapshotFailure(err)
+	}
+	prevSkipEmpty, err := snap.SkipEmpty()
+	if err != nil {
+		return snapshotFailure(err)
+	}
+	// Unless --skip-empty is given, blank templates are treated as before
+	if !cmd.Flags().Changed("skip-empty") {
+		flags.skipEmpty = prevSkipEmpty
+	}
+
+	d, err := loadData(cmd, sources)
+	if err != nil {
+		return err
+	}
+
+	base, err := renderSnapshot(snap, prevData, prevSkipEmpty)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadRenderConfig(templatePath)
+	if err != nil {
+		return err
+	}
+	// Questions answered for the previous render aren't asked again
+	if d, err = previousAnswers(cfg, d, prevData); err != nil {
+		return err
+	}
+	if d, err = askQuestions(cmd, cfg, d); err != nil {
+		return err
+	}
+	if err := validateData(templatePath, cfg, d); err != nil {
+		return err
+	}
+	eng, err := newEngine(cfg)
+	if err != nil {
+		return err
+	}
+	plan, err := collectPlan(templatePath, cfg, eng, d)
+	if err != nil {
+		return err
+	}
+
+	actions, writes, conflicts, err := planUpdate(base, plan)
+	if err != nil {
+		return err
+	}
+
+	if flags.dryRun {
+		return reportUpdate(cmd, actions, conflicts)
+	}
+
+	prev, err := loadManifest(flags.output)
+	if err != nil {
+		return err
+	}
+
+	// Every file was merged in memory, so writes only replace content
+	writer := output.New(true, writerOptions(cfg)...)
+	if _, err := (&render.Plan{Outputs: writes}).Execute(writer); err != nil {
+		return rollbackWrites(writer, wrapWriteError(err, ""))
+	}
+	if _, err := updateManifest(writer, flags.output, prev, plan.Outputs); err != nil {
+		return rollbackWrites(writer, err)
+	}
+	if err := commitWrites(writer); err != nil {
+		return err
+	}
+	if err := saveSnapshot(templatePath, d); err != nil {
+		return err
+	}
+
+	return reportUpdate(cmd, actions, conflicts)
+}
+
+// snapshotFailure converts an error reading or rendering the snapshot.
+func snapshotFailure(err error) error {
+	return &exitError{
+		code: ExitRuntimeError,
+		msg:  fmt.Sprintf("failed to render snapshot: %v", err),
+		err:  err,
+	}
+}
+
+// renderSnapshot renders the templates of the previous render with d, its
+// data, and skipEmpty, its --skip-empty, returning the content of each
+// output by path.
+func renderSnapshot(snap *snapshot.Snapshot, d any, skipEmpty bool) (map[string][]byte, error) {
+	var cfg *config.ParsedConfig
+	var err error
+	if control := snap.ControlFile(); control != "" {
+		cfg, err = config.LoadFile(control, snap.TemplateDir())
+	} else {
+		cfg, err = config.Load(snap.TemplateDir())
+	}
+	if err != nil {
+		return nil, snapshotFailure(err)
+	}
+
+	// The snapshot rendered before, so strict mode has nothing to catch
+	eng := engine.New(engine.AllowEnv(flags.allowEnv))
+	if partials := snap.PartialsDir(); partials != "" {
+		if err := eng.LoadPartials(partials); err != nil {
+			return nil, snapshotFailure(err)
+		}
+	}
+
+	plan, err := render.Collect(render.CollectConfig{
+		TemplateDir: snap.TemplateDir(),
+		OutputDir:   flags.output,
+		Data:        d,
+		Config:      cfg,
+		Engine:      eng,
+		SkipEmpty:   skipEmpty,
+	})
+	if err != nil {
+		return nil, snapshotFailure(err)
+	}
+
+	base := make(map[string][]byte, len(plan.Outputs))
+	for _, out := range plan.Outputs {
+		content, err := outputContent(out)
+		if err != nil {
+			return nil, err
+		}
+		base[out.OutputPath] = content
+	}
+	return base, nil
+}
+
+// collectPlan renders a template directory into a validated plan.
+func collectPlan(templatePath string, cfg *config.ParsedConfig, eng *engine.Engine, d any) (*render.Plan, error) {
+	plan, err := render.Collect(render.CollectConfig{
+		TemplateDir: templatePath,
+		OutputDir:   flags.output,
+		Data:        d,
+		Config:      cfg,
+		Engine:      eng,
+		SkipEmpty:   flags.skipEmpty,
+	})
+	if err != nil {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("failed to collect outputs: %v", err),
+			err:  err,
+		}
+	}
+	if errs := plan.Validate(); len(errs) > 0 {
+		return nil, &exitError{
+			code: ExitRuntimeError,
+			msg:  fmt.Sprintf("validation failed: %v", render.Errors(errs)),
+		}
+	}
+	return plan, nil
+}
+
+// planUpdate merges each output of the new plan into the file on disk,
+// with the previous render as the base. It returns the action for every
+// file, the outputs to write, and the paths of files with conflicts.
+func planUpdate(base map[string][]byte, plan *render.Plan) ([]fileAction, []render.Output, []string, error) {
+	var actions []fileAction
+	var writes []render.Output
+	var conflicts []string
+
+	planned := make(map[string]bool, len(plan.Outputs))
+	for _, out := range plan.Outputs {
+		planned[out.OutputPath] = true
+
+		updated, err := outputContent(out)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		prevContent, inBase := base[out.OutputPath]
+
+		action := fileAction{Path: out.OutputPath}
+		write := render.Output{
+			SourcePath:  out.SourcePath,
+			OutputPath:  out.OutputPath,
+			Permissions: out.Permissions,
+			Overwrite:   true,
+		}
+
+		current, err := os.ReadFile(out.OutputPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if inBase && bytes.Equal(prevContent, updated) {
+				// Deleted on purpose; the template has nothing new for it
+				action.Action = updateDeleted
+			} else {
+				action.Action = updateCreate
+				write.Content = updated
+			}
+		case err != nil:
+			return nil, nil, nil, &exitError{
+				code: ExitRuntimeError,
+				msg:  fmt.Sprintf("failed to read existing file: %v", err),
+				err:  err,
+			}
+		case !out.Overwrite:
+			action.Action = updateNoWrite
+		default:
+			merged, n := diff.Merge(prevContent, current, updated, labelCurrent, labelTemplate)
+			switch {
+			case n > 0:
+				action.Action = updateConflict
+				conflicts = append(conflicts, out.OutputPath)
+			case bytes.Equal(merged, current):
+				action.Action = updateUnchanged
+			case inBase && bytes.Equal(prevContent, current):
+				action.Action = updateUpdate
+			default:
+				action.Action = updateMerge
+			}
+			if !bytes.Equal(merged, current) {
+				write.Content = merged
+			}
+		}
+
+		actions = append(actions, action)
+		if write.Content != nil {
+			writes = append(writes, write)
+		}
+	}
+
+	// Files the template no longer produces are left for the user
+	var removed []string
+	for path := range base {
+		if !planned[path] && output.Exists(path) {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	for _, path := range removed {
+		actions = append(actions, fileAction{Path: path, Action: updateRemoved})
+	}
+
+	return actions, writes, conflicts, nil
+}
+
+// reportUpdate reports the result of an update. Conflicts fail the command
+// with ExitOutputConflict once they are reported.
+func reportUpdate(cmd *cobra.Command, actions []fileAction, conflicts []string) error {
+	status := "success"
+	switch {
+	case flags.dryRun:
+		status = "dry-run"
+	case len(conflicts) > 0:
+		status = "conflict"
+	}
+
+	if flags.jsonOut {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		result := renderResult{Status: status, Files: actions, Conflicts: conflicts}
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		stdout := cmd.OutOrStdout()
+		if flags.dryRun {
+			_, _ = fmt.Fprintln(stdout, "Dry run - would perform:")
+		}
+		for _, a := range actions {
+			if a.Action != updateUnchanged {
+				_, _ = fmt.Fprintf(stdout, "  [%s] %s\n", a.Action, a.Path)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 && !flags.dryRun {
+		return &exitError{
+			code:     ExitOutputConflict,
+			msg:      fmt.Sprintf("%d file(s) have merge conflicts; resolve the conflict markers", len(conflicts)),
+			reported: true,
+		}
+	}
+	return nil
+}
+
+// saveSnapshot records the templates and data of a directory render in the
+// output directory for a later update.
+func saveSnapshot(templatePath string, d any) error {
+	err := snapshot.Save(flags.output, snapshot.Sources{
+		TemplateDir: templatePath,
+		PartialsDir: flags.partials,
+		ControlFile: flags.control,
+		Data:        d,
+		SkipEmpty:   flags.skipEmpty,
+	})
+	if err != nil {
+		return wrapWriteError(err, "")
+	}
+	return nil
+}
@@ -8,17 +8,21 @@ import (
 	"slices"
 	"sort"
 	"strings"
-	"text/template"
 
-	"github.com/wernerstrydom/render/internal/funcs"
+	"github.com/wernerstrydom/render/internal/data"
+	"github.com/wernerstrydom/render/internal/engine"
+	"github.com/wernerstrydom/render/internal/output"
+	"github.com/wernerstrydom/render/internal/prompt"
 	"gopkg.in/yaml.v3"
 )
 
 // PathMapping represents a path mapping which can be either a simple string
-// or an object with path and overwrite options.
+// or an object with path, overwrite, when and skipEmpty options.
 type PathMapping struct {
-	Path      string `json:"path" yaml:"path"`
+	Path      string `json:"path" yaml:"path"`           // "" = keep the path
 	Overwrite *bool  `json:"overwrite" yaml:"overwrite"` // nil = true (default)
+	When      string `json:"when" yaml:"when"`           // Template condition; "" = always emitted
+	SkipEmpty *bool  `json:"skipEmpty" yaml:"skipEmpty"` // nil = as set by --skip-empty
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling to support both string
@@ -36,16 +40,20 @@ func (p *PathMapping) UnmarshalYAML(value *yaml.Node) error {
 		type rawPathMapping struct {
 			Path      string `yaml:"path"`
 			Overwrite *bool  `yaml:"overwrite"`
+			When      string `yaml:"when"`
+			SkipEmpty *bool  `yaml:"skipEmpty"`
 		}
 		var raw rawPathMapping
 		if err := value.Decode(&raw); err != nil {
 			return err
 		}
-		if raw.Path == "" {
-			return fmt.Errorf("path mapping object must have 'path' field")
+		if raw.Path == "" && raw.When == "" && raw.SkipEmpty == nil {
+			return fmt.Errorf("path mapping object must have 'path' field, or 'when' or 'skipEmpty' alone")
 		}
 		p.Path = raw.Path
 		p.Overwrite = raw.Overwrite
+		p.When = raw.When
+		p.SkipEmpty = raw.SkipEmpty
 		return nil
 	}
 
@@ -54,28 +62,46 @@ func (p *PathMapping) UnmarshalYAML(value *yaml.Node) error {
 
 // Config represents the raw .render.yaml configuration.
 type Config struct {
-	Paths map[string]PathMapping `json:"paths" yaml:"paths"`
+	Paths   map[string]PathMapping    `json:"paths" yaml:"paths"`
+	Strict  bool                      `json:"strict" yaml:"strict"`   // Fail on missing keys
+	Regions map[string]output.Markers `json:"regions" yaml:"regions"` // Protected region markers by file extension
+	Schema  string                    `json:"schema" yaml:"schema"`   // JSON Schema for the data, relative to the template directory
+
+	Defaults  any               `json:"defaults" yaml:"defaults"`   // Values the data is merged over, or a YAML or JSON file of them relative to the template directory
+	Questions []prompt.Question `json:"questions" yaml:"questions"` // Values to ask for when the data omits them
 }
 
-// dirMapping holds a directory prefix mapping with its parsed template.
+// dirMapping holds a directory prefix mapping with its compiled template.
 type dirMapping struct {
 	prefix string
-	tmpl   *template.Template
+	tmpl   *engine.Template
 }
 
-// ParsedConfig holds validated, pre-parsed configuration.
+// ParsedConfig holds validated, pre-compiled configuration.
 type ParsedConfig struct {
-	fileTemplates map[string]*template.Template // Exact file mappings
-	dirMappings   []dirMapping                  // Prefix mappings, sorted longest first
-	noOverwrite   map[string]bool               // Source paths with overwrite: false
+	fileTemplates map[string]*engine.Template // Exact file mappings
+	dirMappings   []dirMapping                // Prefix mappings, sorted longest first
+	noOverwrite   map[string]bool             // Source paths with overwrite: false
+	conditions    map[string]*engine.Template // when: conditions by slash-separated source path
+	skipEmpty     map[string]bool             // skipEmpty: settings by slash-separated source path
+	strict        bool                        // strict: true was set
+	regions       map[string]output.Markers   // Protected region markers by file extension
+	schema        string                      // Schema path relative to the template directory
+	defaults      map[string]any              // Values the data is merged over
+	defaultsFile  string                      // File defaults: names, relative to the template directory
+	questions     []prompt.Question           // Values to ask for when the data omits them
 }
 
+// knownKeys lists the top-level keys allowed in a config file.
+var knownKeys = []string{"paths", "strict", "regions", "schema", "defaults", "questions"}
+
 // configFileNames lists the supported config file names in priority order.
 var configFileNames = []string{".render.yaml", ".render.yml", "render.json"}
 
 // Load finds and loads a render config from the template directory.
 // Returns nil (not an error) if no config file exists.
-func Load(tmplDir string) (*ParsedConfig, error) {
+// The engine options apply to the compiled path templates.
+func Load(tmplDir string, opts ...engine.Option) (*ParsedConfig, error) {
 	// Try each config file name in order
 	var configPath string
 	for _, name := range configFileNames {
@@ -91,21 +117,23 @@ func Load(tmplDir string) (*ParsedConfig, error) {
 		return nil, nil
 	}
 
-	return LoadFile(configPath, tmplDir)
+	return LoadFile(configPath, tmplDir, opts...)
 }
 
 // LoadFile loads and parses a config file.
-func LoadFile(configPath, tmplDir string) (*ParsedConfig, error) {
+func LoadFile(configPath, tmplDir string, opts ...engine.Option) (*ParsedConfig, error) {
 	content, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	return Parse(content, tmplDir, filepath.Base(configPath))
+	return Parse(content, tmplDir, filepath.Base(configPath), opts...)
 }
 
 // Parse parses config content and validates it against the template directory.
-func Parse(content []byte, tmplDir, filename string) (*ParsedConfig, error) {
+// Path templates are compiled with the given engine options; strict: true in
+// the config additionally enables engine.Strict.
+func Parse(content []byte, tmplDir, filename string, opts ...engine.Option) (*ParsedConfig, error) {
 	// First, validate schema by parsing into raw map
 	var raw map[string]any
 	if err := yaml.Unmarshal(content, &raw); err != nil {
@@ -114,8 +142,8 @@ func Parse(content []byte, tmplDir, filename string) (*ParsedConfig, error) {
 
 	// Check for unknown keys
 	for key := range raw {
-		if key != "paths" {
-			return nil, fmt.Errorf("%s: unknown key %q (only 'paths' allowed)", filename, key)
+		if !slices.Contains(knownKeys, key) {
+			return nil, fmt.Errorf("%s: unknown key %q (allowed: %s)", filename, key, strings.Join(knownKeys, ", "))
 		}
 	}
 
@@ -125,24 +153,81 @@ func Parse(content []byte, tmplDir, filename string) (*ParsedConfig, error) {
 		return nil, fmt.Errorf("%s: failed to parse config: %w", filename, err)
 	}
 
+	for ext, markers := range cfg.Regions {
+		if err := validateRegionMarkers(ext, markers); err != nil {
+			return nil, fmt.Errorf("%s: regions[%q]: %w", filename, ext, err)
+		}
+	}
+
+	if cfg.Schema != "" {
+		if err := validateSourcePath(cfg.Schema); err != nil {
+			return nil, fmt.Errorf("%s: schema: %w", filename, err)
+		}
+		if _, err := os.Stat(filepath.Join(tmplDir, cfg.Schema)); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%s: schema: %q does not exist in template directory", filename, cfg.Schema)
+			}
+			return nil, fmt.Errorf("%s: schema: %w", filename, err)
+		}
+	}
+
+	if cfg.Strict {
+		opts = append(opts, engine.Strict(true))
+	}
+	eng := engine.New(opts...)
+
+	seen := make(map[string]bool, len(cfg.Questions))
+	for i := range cfg.Questions {
+		q := &cfg.Questions[i]
+		if err := q.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: questions[%d]: %w", filename, i, err)
+		}
+		if err := q.Compile(eng); err != nil {
+			return nil, fmt.Errorf("%s: questions[%d]: %w", filename, i, err)
+		}
+		if seen[q.Name] {
+			return nil, fmt.Errorf("%s: questions[%d]: %q is asked more than once", filename, i, q.Name)
+		}
+		seen[q.Name] = true
+	}
+
+	var defaults map[string]any
+	var defaultsFile string
+	if _, ok := raw["defaults"]; ok {
+		var err error
+		if defaults, defaultsFile, err = parseDefaults(cfg.Defaults, tmplDir); err != nil {
+			return nil, fmt.Errorf("%s: defaults: %w", filename, err)
+		}
+	}
+
 	// Empty config is valid but has nothing to transform
 	if len(cfg.Paths) == 0 {
 		return &ParsedConfig{
-			fileTemplates: make(map[string]*template.Template),
+			fileTemplates: make(map[string]*engine.Template),
 			dirMappings:   nil,
 			noOverwrite:   make(map[string]bool),
+			strict:        cfg.Strict,
+			regions:       cfg.Regions,
+			schema:        filepath.Clean(cfg.Schema),
+			defaults:      defaults,
+			defaultsFile:  defaultsFile,
+			questions:     cfg.Questions,
 		}, nil
 	}
 
 	// Validate and parse all path mappings
 	parsed := &ParsedConfig{
-		fileTemplates: make(map[string]*template.Template),
+		fileTemplates: make(map[string]*engine.Template),
 		dirMappings:   nil,
 		noOverwrite:   make(map[string]bool),
+		strict:        cfg.Strict,
+		regions:       cfg.Regions,
+		schema:        filepath.Clean(cfg.Schema),
+		defaults:      defaults,
+		defaultsFile:  defaultsFile,
+		questions:     cfg.Questions,
 	}
 
-	funcMap := funcs.Map()
-
 	for src, mapping := range cfg.Paths {
 		// Validate source path
 		if err := validateSourcePath(src); err != nil {
@@ -159,17 +244,38 @@ func Parse(content []byte, tmplDir, filename string) (*ParsedConfig, error) {
 			return nil, fmt.Errorf("%s: paths[%q]: %w", filename, src, err)
 		}
 
-		// Parse the destination template
-		tmpl, err := template.New(src).Funcs(funcMap).Parse(mapping.Path)
-		if err != nil {
-			return nil, fmt.Errorf("%s: paths[%q]: invalid template syntax: %w", filename, src, err)
-		}
-
 		// Track overwrite setting (nil or true means overwrite allowed; false means no overwrite)
 		if mapping.Overwrite != nil && !*mapping.Overwrite {
 			parsed.noOverwrite[src] = true
 		}
 
+		key := filepath.ToSlash(filepath.Clean(src))
+		if mapping.When != "" {
+			cond, err := eng.Compile(src+" (when)", mapping.When)
+			if err != nil {
+				return nil, fmt.Errorf("%s: paths[%q]: invalid when template syntax: %w", filename, src, err)
+			}
+			if parsed.conditions == nil {
+				parsed.conditions = make(map[string]*engine.Template)
+			}
+			parsed.conditions[key] = cond
+		}
+		if mapping.SkipEmpty != nil {
+			if parsed.skipEmpty == nil {
+				parsed.skipEmpty = make(map[string]bool)
+			}
+			parsed.skipEmpty[key] = *mapping.SkipEmpty
+		}
+		if mapping.Path == "" {
+			continue
+		}
+
+		// Compile the destination template
+		tmpl, err := eng.Compile(src, mapping.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: paths[%q]: invalid template syntax: %w", filename, src, err)
+		}
+
 		if info.IsDir() {
 			// Directory prefix mapping
 			parsed.dirMappings = append(parsed.dirMappings, dirMapping{
@@ -190,6 +296,36 @@ func Parse(content []byte, tmplDir, filename string) (*ParsedConfig, error) {
 	return parsed, nil
 }
 
+// parseDefaults returns the defaults declared by defaults:, either a
+// mapping or the name of a YAML or JSON file of them relative to tmplDir,
+// along with the file name, if any.
+func parseDefaults(value any, tmplDir string) (map[string]any, string, error) {
+	switch v := value.(type) {
+	case nil:
+		return map[string]any{}, "", nil
+	case map[string]any:
+		return v, "", nil
+	case string:
+		if err := validateSourcePath(v); err != nil {
+			return nil, "", err
+		}
+		d, err := data.Load(filepath.Join(tmplDir, v))
+		if err != nil {
+			return nil, "", err
+		}
+		if d == nil {
+			return map[string]any{}, filepath.Clean(v), nil
+		}
+		defaults, ok := d.(map[string]any)
+		if !ok {
+			return nil, "", fmt.Errorf("%s must hold a mapping", v)
+		}
+		return defaults, filepath.Clean(v), nil
+	default:
+		return nil, "", fmt.Errorf("must be a mapping or a file name")
+	}
+}
+
 // validateSourcePath checks that a source path is safe.
 func validateSourcePath(path string) error {
 	// Check for absolute path
@@ -213,6 +349,20 @@ func validateSourcePath(path string) error {
 	return nil
 }
 
+// validateRegionMarkers checks the protected region markers for an extension.
+func validateRegionMarkers(ext string, m output.Markers) error {
+	if !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, `/\`) {
+		return fmt.Errorf("key must be a file extension such as \".go\"")
+	}
+	if strings.TrimSpace(m.Begin) == "" || strings.TrimSpace(m.End) == "" {
+		return fmt.Errorf("both begin and end markers are required")
+	}
+	if strings.Contains(m.End, m.Begin) {
+		return fmt.Errorf("end marker must not contain the begin marker")
+	}
+	return nil
+}
+
 // ValidateRenderedPath checks that a rendered output path is safe.
 func ValidateRenderedPath(path string) error {
 	// Check for path traversal in rendered output
@@ -226,9 +376,75 @@ func ValidateRenderedPath(path string) error {
 	return nil
 }
 
-// IsEmpty returns true if the config has no path mappings.
+// IsEmpty returns true if the config has no path mappings, conditions or
+// skipEmpty settings.
 func (p *ParsedConfig) IsEmpty() bool {
-	return p == nil || (len(p.fileTemplates) == 0 && len(p.dirMappings) == 0)
+	return p == nil || (len(p.fileTemplates) == 0 && len(p.dirMappings) == 0 &&
+		len(p.conditions) == 0 && len(p.skipEmpty) == 0)
+}
+
+// Strict returns true if the config sets strict: true.
+func (p *ParsedConfig) Strict() bool {
+	return p != nil && p.strict
+}
+
+// Schema returns the path of the schema set by schema:, relative to the
+// template directory, or "" if the config sets none.
+func (p *ParsedConfig) Schema() string {
+	if p == nil || p.schema == "." {
+		return ""
+	}
+	return p.schema
+}
+
+// DefaultsFile returns the path of the file defaults: names, relative to
+// the template directory, or "" if the config names none.
+func (p *ParsedConfig) DefaultsFile() string {
+	if p == nil {
+		return ""
+	}
+	return p.defaultsFile
+}
+
+// Defaults returns the default values declared by defaults:, or nil if
+// there are none.
+func (p *ParsedConfig) Defaults() map[string]any {
+	if p == nil {
+		return nil
+	}
+	return p.defaults
+}
+
+// ApplyDefaults deep-merges d over the defaults, so values in d win and
+// keys it omits take their default. d is returned unchanged if there are no
+// defaults.
+func (p *ParsedConfig) ApplyDefaults(d any) any {
+	defaults := p.Defaults()
+	if defaults == nil {
+		return d
+	}
+	if d == nil {
+		return defaults
+	}
+	return data.Merge(defaults, d, data.MergeOptions{})
+}
+
+// Questions returns the questions to ask for values the data omits, in
+// order, or nil if the config declares none.
+func (p *ParsedConfig) Questions() []prompt.Question {
+	if p == nil {
+		return nil
+	}
+	return p.questions
+}
+
+// Regions returns the protected region markers by file extension,
+// or nil if the config sets none.
+func (p *ParsedConfig) Regions() map[string]output.Markers {
+	if p == nil {
+		return nil
+	}
+	return p.regions
 }
 
 // HasFileMappings returns true if there are exact file mappings.
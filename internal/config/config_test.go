@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/wernerstrydom/render/internal/engine"
+	"github.com/wernerstrydom/render/internal/output"
 )
 
 func TestParse_ValidConfig(t *testing.T) {
@@ -248,6 +253,8 @@ func TestShouldSkipConfigFile(t *testing.T) {
 		{".render.yaml", true},
 		{".render.yml", true},
 		{"render.json", true},
+		{"render.schema.json", false}, // Only when schema: names it
+		{"defaults.yaml", false},      // Only when defaults: names it
 		{"other.yaml", false},
 		{"config.yaml", false},
 		{"src/.render.yaml", false}, // Only top-level
@@ -373,6 +380,232 @@ func TestParse_PathMappingObjectMissingPath(t *testing.T) {
 
 // Helper functions
 
+func TestParse_Strict(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.go.tmpl", "package x")
+
+	content := []byte(`strict: true
+paths:
+  "model.go.tmpl": "{{ .name }}.go"
+`)
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !parsed.Strict() {
+		t.Error("Strict() = false, want true")
+	}
+
+	_, err = NewPathMapper(parsed).TransformPath("model.go.tmpl", map[string]any{})
+	if err == nil {
+		t.Fatal("Expected missing key error from strict path template")
+	}
+	var mk *engine.MissingKeyError
+	if !errors.As(err, &mk) {
+		t.Fatalf("Expected MissingKeyError, got %T: %v", err, err)
+	}
+	if mk.Template != "model.go.tmpl" || mk.Key != "name" {
+		t.Errorf("MissingKeyError = %+v", mk)
+	}
+}
+
+func TestParse_Regions(t *testing.T) {
+	dir := t.TempDir()
+
+	content := []byte(`regions:
+  ".sql":
+    begin: "-- BEGIN CUSTOM"
+    end: "-- END CUSTOM"
+`)
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := output.Markers{Begin: "-- BEGIN CUSTOM", End: "-- END CUSTOM"}
+	if got := parsed.Regions()[".sql"]; got != want {
+		t.Errorf("Regions()[.sql] = %+v, want %+v", got, want)
+	}
+
+	invalid := map[string]string{
+		"not an extension":   "regions:\n  sql:\n    begin: a\n    end: b\n",
+		"missing end":        "regions:\n  .sql:\n    begin: a\n",
+		"end contains begin": "regions:\n  .sql:\n    begin: keep\n    end: keep end\n",
+	}
+	for name, content := range invalid {
+		if _, err := Parse([]byte(content), dir, ".render.yaml"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParse_Schema(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "schemas/service.json", "{}")
+
+	parsed, err := Parse([]byte("schema: schemas/service.json\n"), dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, want := parsed.Schema(), filepath.Join("schemas", "service.json"); got != want {
+		t.Errorf("Schema() = %q, want %q", got, want)
+	}
+
+	parsed, err = Parse([]byte("strict: true\n"), dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := parsed.Schema(); got != "" {
+		t.Errorf("Schema() = %q, want \"\"", got)
+	}
+
+	invalid := map[string]string{
+		"missing":   "schema: missing.json\n",
+		"traversal": "schema: ../service.json\n",
+	}
+	for name, content := range invalid {
+		if _, err := Parse([]byte(content), dir, ".render.yaml"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	dir := t.TempDir()
+
+	parsed, err := Parse([]byte("defaults:\n  port: 8080\n  db:\n    host: localhost\n"), dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := map[string]any{"port": 8080, "db": map[string]any{"host": "localhost"}}
+	if got := parsed.Defaults(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Defaults() = %v, want %v", got, want)
+	}
+
+	// Data wins over the defaults, and nested objects are merged
+	got := parsed.ApplyDefaults(map[string]any{"db": map[string]any{"name": "app"}, "port": 9090})
+	want = map[string]any{"port": 9090, "db": map[string]any{"host": "localhost", "name": "app"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyDefaults() = %v, want %v", got, want)
+	}
+
+	parsed, err = Parse([]byte("strict: true\n"), dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.Defaults() != nil {
+		t.Errorf("Defaults() = %v, want nil", parsed.Defaults())
+	}
+	d := map[string]any{"a": 1}
+	if got := parsed.ApplyDefaults(d); !reflect.DeepEqual(got, d) {
+		t.Errorf("ApplyDefaults() = %v, want the data unchanged", got)
+	}
+}
+
+func TestParse_Questions(t *testing.T) {
+	dir := t.TempDir()
+
+	content := []byte(`questions:
+  - name: name
+    prompt: Service name
+  - name: port
+    type: int
+    default: 8080
+`)
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	questions := parsed.Questions()
+	if len(questions) != 2 || questions[0].Prompt != "Service name" || questions[1].Default != 8080 {
+		t.Errorf("Questions() = %+v", questions)
+	}
+
+	invalid := map[string]string{
+		"duplicate":    "questions:\n  - name: a\n  - name: a\n",
+		"unknown type": "questions:\n  - name: a\n    type: float\n",
+	}
+	for name, content := range invalid {
+		if _, err := Parse([]byte(content), dir, ".render.yaml"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_DefaultsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "defaults.yaml", "port: 8080\n")
+
+	// Without a config file naming it, defaults.yaml is an ordinary file
+	cfg, err := Load(dir)
+	if err != nil || cfg != nil {
+		t.Fatalf("Load() = %v, %v; want no config", cfg, err)
+	}
+
+	writeFile(t, dir, ".render.yaml", "defaults: defaults.yaml\n")
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cfg.Defaults(); !reflect.DeepEqual(got, map[string]any{"port": 8080}) {
+		t.Errorf("Defaults() = %v", got)
+	}
+	if cfg.DefaultsFile() != "defaults.yaml" {
+		t.Errorf("DefaultsFile() = %q, want defaults.yaml", cfg.DefaultsFile())
+	}
+
+	invalid := map[string]string{
+		"not a mapping": "- 8080\n",
+		"missing":       "",
+	}
+	for name, content := range invalid {
+		other := t.TempDir()
+		if content != "" {
+			writeFile(t, other, "defaults.yaml", content)
+		}
+		writeFile(t, other, ".render.yaml", "defaults: defaults.yaml\n")
+		if _, err := Load(other); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := Parse([]byte("defaults: ../defaults.yaml\n"), dir, ".render.yaml"); err == nil {
+		t.Error("expected error for defaults outside the template directory")
+	}
+}
+
+func TestParse_StrictOption(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "model.go.tmpl", "package x")
+
+	content := []byte(`paths:
+  "model.go.tmpl": "{{ .name }}.go"
+`)
+
+	// Without strict, a missing key renders as <no value>
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.Strict() {
+		t.Error("Strict() = true, want false")
+	}
+	result, err := NewPathMapper(parsed).TransformPath("model.go.tmpl", map[string]any{})
+	if err != nil {
+		t.Fatalf("TransformPath failed: %v", err)
+	}
+	if result != "<no value>.go" {
+		t.Errorf("TransformPath = %q, want %q", result, "<no value>.go")
+	}
+
+	// The engine option enables strict mode even without strict: true
+	parsed, err = Parse(content, dir, ".render.yaml", engine.Strict(true))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := NewPathMapper(parsed).TransformPath("model.go.tmpl", map[string]any{}); err == nil {
+		t.Error("Expected missing key error with engine.Strict option")
+	}
+}
+
 func writeFile(t *testing.T, dir, name, content string) {
 	t.Helper()
 	path := filepath.Join(dir, name)
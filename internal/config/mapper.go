@@ -1,7 +1,7 @@
 package config
 
 import (
-	"bytes"
+	"path/filepath"
 	"slices"
 	"strings"
 )
@@ -36,11 +36,11 @@ func (m *PathMapper) TransformPath(relPath string, data any) (string, error) {
 
 	// Step 1: Check for exact file match first
 	if tmpl, ok := m.parsed.fileTemplates[relPath]; ok {
-		var buf bytes.Buffer
-		if err := tmpl.Execute(&buf, data); err != nil {
+		rendered, err := tmpl.Execute(data)
+		if err != nil {
 			return "", err
 		}
-		result = buf.String()
+		result = rendered
 
 		// Validate rendered path
 		if err := ValidateRenderedPath(result); err != nil {
@@ -53,11 +53,10 @@ func (m *PathMapper) TransformPath(relPath string, data any) (string, error) {
 	for _, dm := range m.parsed.dirMappings {
 		if strings.HasPrefix(result, dm.prefix+"/") || result == dm.prefix {
 			// Render the prefix template
-			var buf bytes.Buffer
-			if err := dm.tmpl.Execute(&buf, data); err != nil {
+			newPrefix, err := dm.tmpl.Execute(data)
+			if err != nil {
 				return "", err
 			}
-			newPrefix := buf.String()
 
 			// Validate rendered prefix
 			if err := ValidateRenderedPath(newPrefix); err != nil {
@@ -73,6 +72,45 @@ func (m *PathMapper) TransformPath(relPath string, data any) (string, error) {
 	return result, nil
 }
 
+// Include reports whether relPath is emitted: false if the when: condition
+// of the path, or of a directory containing it, renders as false, 0, an
+// empty string or <no value> for the data.
+func (m *PathMapper) Include(relPath string, data any) (bool, error) {
+	if m == nil || m.parsed == nil || len(m.parsed.conditions) == 0 {
+		return true, nil
+	}
+
+	// Check the outermost directory first
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range parts {
+		cond, ok := m.parsed.conditions[strings.Join(parts[:i+1], "/")]
+		if !ok {
+			continue
+		}
+		ok, err := cond.IsTrue(data)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// SkipEmpty reports whether relPath is left out when it renders empty or
+// whitespace-only: as set by skipEmpty: on the path or, failing that, on the
+// nearest directory containing it, else def.
+func (m *PathMapper) SkipEmpty(relPath string, def bool) bool {
+	if m == nil || m.parsed == nil {
+		return def
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := len(parts); i > 0; i-- {
+		if skip, ok := m.parsed.skipEmpty[strings.Join(parts[:i], "/")]; ok {
+			return skip
+		}
+	}
+	return def
+}
+
 // CanOverwrite returns true if the source path allows overwriting existing files.
 // Returns true (default) if no explicit overwrite:false is set for this path.
 func (m *PathMapper) CanOverwrite(sourcePath string) bool {
@@ -82,8 +120,9 @@ func (m *PathMapper) CanOverwrite(sourcePath string) bool {
 	return !m.parsed.noOverwrite[sourcePath]
 }
 
-// ShouldSkipConfigFile returns true if the path is a render config file
-// that should not be copied to output.
+// ShouldSkipConfigFile returns true if the path is a render config file,
+// which should not be copied to output. The files the config names as its
+// schema and defaults are skipped too; see Schema and DefaultsFile.
 func ShouldSkipConfigFile(relPath string) bool {
 	return slices.Contains(configFileNames, relPath)
 }
@@ -269,3 +269,90 @@ func mkdirAll(t *testing.T, dir, path string) {
 		t.Fatalf("Failed to create directory: %v", err)
 	}
 }
+
+func TestPathMapper_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "Dockerfile.tmpl", "FROM scratch")
+	writeFile(t, dir, "deploy/k8s/app.yaml", "kind: Deployment")
+
+	content := []byte(`paths:
+  "Dockerfile.tmpl":
+    when: "{{ .docker.enabled }}"
+  "deploy":
+    path: "ops"
+    when: "{{ .deploy }}"
+`)
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	mapper := NewPathMapper(parsed)
+
+	tests := []struct {
+		path string
+		data map[string]any
+		want bool
+	}{
+		{"Dockerfile.tmpl", map[string]any{"docker": map[string]any{"enabled": true}}, true},
+		{"Dockerfile.tmpl", map[string]any{"docker": map[string]any{"enabled": false}}, false},
+		{"Dockerfile.tmpl", map[string]any{}, false}, // <no value>
+		{"deploy/k8s/app.yaml", map[string]any{"deploy": "yes"}, true},
+		{"deploy/k8s/app.yaml", map[string]any{"deploy": 0}, false},
+		{"deploy", map[string]any{"deploy": ""}, false},
+		{"other.txt", map[string]any{}, true},
+	}
+	for _, tt := range tests {
+		got, err := mapper.Include(filepath.FromSlash(tt.path), tt.data)
+		if err != nil {
+			t.Fatalf("Include(%q) failed: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("Include(%q, %v) = %v, want %v", tt.path, tt.data, got, tt.want)
+		}
+	}
+
+	// A condition alone keeps the path
+	result, err := mapper.TransformPath("Dockerfile.tmpl", map[string]any{})
+	if err != nil || result != "Dockerfile.tmpl" {
+		t.Errorf("TransformPath = %q, %v, want the path unchanged", result, err)
+	}
+}
+
+func TestPathMapper_SkipEmpty(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "gen/a.go.tmpl", "")
+	writeFile(t, dir, "gen/keep.go.tmpl", "")
+
+	content := []byte(`paths:
+  "gen":
+    skipEmpty: true
+  "gen/keep.go.tmpl":
+    skipEmpty: false
+`)
+	parsed, err := Parse(content, dir, ".render.yaml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	mapper := NewPathMapper(parsed)
+
+	tests := []struct {
+		path string
+		def  bool
+		want bool
+	}{
+		{"gen/a.go.tmpl", false, true},
+		{"gen/keep.go.tmpl", true, false}, // The file's own setting wins
+		{"other.tmpl", true, true},
+		{"other.tmpl", false, false},
+	}
+	for _, tt := range tests {
+		if got := mapper.SkipEmpty(filepath.FromSlash(tt.path), tt.def); got != tt.want {
+			t.Errorf("SkipEmpty(%q, %v) = %v, want %v", tt.path, tt.def, got, tt.want)
+		}
+	}
+
+	var nilMapper *PathMapper
+	if !nilMapper.SkipEmpty("a.tmpl", true) {
+		t.Error("nil mapper should return the default")
+	}
+}
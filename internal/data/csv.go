@@ -0,0 +1,189 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions configures how CSV and TSV data is parsed.
+type CSVOptions struct {
+	Delimiter rune              // Field delimiter; 0 uses ',' for CSV and tab for TSV
+	NoHeader  bool              // The first row is data, not column names
+	Columns   []string          // Column names, instead of the header or column1, column2, ...
+	Infer     bool              // Convert numbers and booleans in columns without a type
+	Types     map[string]string // Column name → string, int, float or bool
+}
+
+// Option configures how data is parsed.
+type Option func(*options)
+
+type options struct {
+	csv CSVOptions
+	dir DirLayout
+}
+
+// CSV sets the options for CSV and TSV data.
+func CSV(opts CSVOptions) Option {
+	return func(o *options) {
+		o.csv = opts
+	}
+}
+
+// parseCSV parses delimited data into a list with one object per row,
+// keyed by column name. Cells are strings unless the column has a type or
+// opts.Infer is set; an empty cell in a typed column is null.
+func parseCSV(content []byte, delimiter rune, opts CSVOptions) (any, error) {
+	if opts.Delimiter != 0 {
+		delimiter = opts.Delimiter
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	r.Comma = delimiter
+	if delimiter == '\t' {
+		// TSV rarely quotes, so quotes inside fields are literal
+		r.LazyQuotes = true
+	}
+
+	var columns []string
+	rows := make([]any, 0)
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+
+		if columns == nil {
+			if columns, err = csvColumns(record, opts); err != nil {
+				return nil, err
+			}
+			if !opts.NoHeader {
+				continue
+			}
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, cell := range record {
+			name := columns[i]
+			if row[name], err = csvValue(cell, opts.Types[name], opts.Infer); err != nil {
+				line, _ := r.FieldPos(i)
+				return nil, fmt.Errorf("failed to parse CSV: line %d, column %q: %w", line, name, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvColumns returns the column names for a file whose first record is
+// first, and checks the column types refer to them.
+func csvColumns(first []string, opts CSVOptions) ([]string, error) {
+	var columns []string
+	switch {
+	case len(opts.Columns) > 0:
+		if len(opts.Columns) != len(first) {
+			return nil, fmt.Errorf("failed to parse CSV: %d column name(s) given for %d column(s)", len(opts.Columns), len(first))
+		}
+		columns = opts.Columns
+	case opts.NoHeader:
+		for i := range first {
+			columns = append(columns, fmt.Sprintf("column%d", i+1))
+		}
+	default:
+		for _, name := range first {
+			columns = append(columns, strings.TrimSpace(name))
+		}
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for _, name := range columns {
+		if name == "" {
+			return nil, fmt.Errorf("failed to parse CSV: empty column name")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("failed to parse CSV: duplicate column %q", name)
+		}
+		seen[name] = true
+	}
+	for name, typ := range opts.Types {
+		if !seen[name] {
+			return nil, fmt.Errorf("failed to parse CSV: type given for unknown column %q", name)
+		}
+		if _, err := csvValue("", typ, false); err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: column %q: %w", name, err)
+		}
+	}
+	return columns, nil
+}
+
+// csvValue converts a cell to the column type, or infers its type when the
+// column has none and infer is set.
+func csvValue(cell, typ string, infer bool) (any, error) {
+	switch typ {
+	case "":
+		if infer {
+			return inferValue(cell), nil
+		}
+		return cell, nil
+	case "string":
+		return cell, nil
+	case "int", "float", "bool":
+		if strings.TrimSpace(cell) == "" {
+			return nil, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q (expected string, int, float or bool)", typ)
+	}
+
+	s := strings.TrimSpace(cell)
+	switch typ {
+	case "int":
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %q", cell)
+		}
+		return n, nil
+	case "float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q", cell)
+		}
+		return f, nil
+	default:
+		b, err := strconv.ParseBool(strings.ToLower(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %q", cell)
+		}
+		return b, nil
+	}
+}
+
+// inferValue converts a cell that looks like a number or boolean. Numbers
+// with leading zeros, such as ZIP codes, stay strings.
+func inferValue(cell string) any {
+	switch strings.ToLower(cell) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	digits := strings.TrimPrefix(cell, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return cell
+	}
+	if n, err := strconv.Atoi(cell); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil && !strings.ContainsAny(cell, "xXpPnN") {
+		return f
+	}
+	return cell
+}
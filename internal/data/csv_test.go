@@ -0,0 +1,146 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	content := "\ufeffname,age,active,zip\nann,31,true,02134\n\"bob, jr\",,FALSE,10001\n"
+
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want []any
+	}{
+		{
+			name: "strings by default",
+			want: []any{
+				map[string]any{"name": "ann", "age": "31", "active": "true", "zip": "02134"},
+				map[string]any{"name": "bob, jr", "age": "", "active": "FALSE", "zip": "10001"},
+			},
+		},
+		{
+			name: "inferred",
+			opts: CSVOptions{Infer: true},
+			want: []any{
+				map[string]any{"name": "ann", "age": 31, "active": true, "zip": "02134"},
+				map[string]any{"name": "bob, jr", "age": "", "active": false, "zip": 10001},
+			},
+		},
+		{
+			name: "typed",
+			opts: CSVOptions{Infer: true, Types: map[string]string{"age": "int", "zip": "string"}},
+			want: []any{
+				map[string]any{"name": "ann", "age": 31, "active": true, "zip": "02134"},
+				map[string]any{"name": "bob, jr", "age": nil, "active": false, "zip": "10001"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(content), "csv", CSV(tt.opts))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSV_Headers(t *testing.T) {
+	content := "ann\t31\nbob\t42\n"
+
+	got, err := Parse([]byte(content), "tsv", CSV(CSVOptions{NoHeader: true}))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []any{
+		map[string]any{"column1": "ann", "column2": "31"},
+		map[string]any{"column1": "bob", "column2": "42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	got, err = Parse([]byte(content), "tsv", CSV(CSVOptions{NoHeader: true, Columns: []string{"name", "age"}, Types: map[string]string{"age": "int"}}))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want = []any{
+		map[string]any{"name": "ann", "age": 31},
+		map[string]any{"name": "bob", "age": 42},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	// Columns replace the header row
+	got, err = Parse([]byte("a;b\n1;2\n"), "csv", CSV(CSVOptions{Delimiter: ';', Columns: []string{"x", "y"}}))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := []any{map[string]any{"x": "1", "y": "2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	got, err = Parse([]byte("name,age\n"), "csv")
+	if err != nil || !reflect.DeepEqual(got, []any{}) {
+		t.Errorf("Parse() of header only = %#v, %v; want empty list", got, err)
+	}
+}
+
+func TestParseCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    CSVOptions
+		want    string
+	}{
+		{"ragged rows", "a,b\n1\n", CSVOptions{}, "wrong number of fields"},
+		{"duplicate column", "a,a\n1,2\n", CSVOptions{}, `duplicate column "a"`},
+		{"empty column", "a,\n1,2\n", CSVOptions{}, "empty column name"},
+		{"column count", "a,b\n1,2\n", CSVOptions{Columns: []string{"x"}}, "1 column name(s) given for 2"},
+		{"unknown column type", "a\n1\n", CSVOptions{Types: map[string]string{"b": "int"}}, `unknown column "b"`},
+		{"unknown type", "a\n1\n", CSVOptions{Types: map[string]string{"a": "date"}}, `unknown type "date"`},
+		{"invalid int", "a\n1\nx\n", CSVOptions{Types: map[string]string{"a": "int"}}, `line 3, column "a": invalid int "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content), "csv", CSV(tt.opts))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"42", 42},
+		{"-7", -7},
+		{"0", 0},
+		{"0.5", 0.5},
+		{"1e3", 1000.0},
+		{"True", true},
+		{"007", "007"},
+		{"NaN", "NaN"},
+		{"inf", "inf"},
+		{"0x10", "0x10"},
+		{"", ""},
+		{"yes", "yes"},
+	}
+	for _, tt := range tests {
+		if got := inferValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("inferValue(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// FileKey is the key DirList adds to each object, holding the path of the
+// file it came from relative to the directory.
+const FileKey = "_file"
+
+// DirLayout selects how the files of a directory data source are combined.
+type DirLayout int
+
+const (
+	// DirObject keys each file's data by its path relative to the
+	// directory, without the extension, such as "api" or "team/web".
+	DirObject DirLayout = iota
+	// DirList lists each file's data in path order, adding FileKey to
+	// objects.
+	DirList
+)
+
+// ParseDirLayout parses a directory layout name: object or list.
+func ParseDirLayout(name string) (DirLayout, error) {
+	switch name {
+	case "object":
+		return DirObject, nil
+	case "list":
+		return DirList, nil
+	default:
+		return 0, fmt.Errorf("unknown directory layout %q (expected object or list)", name)
+	}
+}
+
+// Dir sets how the files of a directory data source are combined.
+func Dir(layout DirLayout) Option {
+	return func(o *options) {
+		o.dir = layout
+	}
+}
+
+// loadDir loads every data file under dir, recursively and in path order.
+// Files with an unrecognized extension and hidden files and directories are
+// skipped, so a README next to the data does no harm.
+func loadDir(dir string, opts ...Option) (any, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	object := make(map[string]any)
+	from := make(map[string]string) // object key → file it came from
+	list := make([]any, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, err := DetectFormat(path); err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		v, err := Load(path, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+
+		if o.dir == DirList {
+			if m, ok := v.(map[string]any); ok {
+				m[FileKey] = rel
+			}
+			list = append(list, v)
+			return nil
+		}
+
+		key := strings.TrimSuffix(rel, filepath.Ext(rel))
+		if prev, ok := from[key]; ok {
+			return fmt.Errorf("%s and %s both have the key %q", prev, rel, key)
+		}
+		from[key] = rel
+		object[key] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.dir == DirList {
+		return list, nil
+	}
+	return object, nil
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeDataDir creates files relative to a new temporary directory.
+func writeDataDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeDataDir(t, map[string]string{
+		"api.yaml":          "name: api\nport: 8080\n",
+		"team/web.json":     `{"name": "web", "port": 80}`,
+		"README.md":         "# Services",
+		".hidden.yaml":      "name: hidden\n",
+		".git/config.yaml":  "name: git\n",
+		"team/tags.yaml":    "- a\n- b\n",
+		"team/empty/x.toml": "",
+	})
+
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]any{
+		"api":          map[string]any{"name": "api", "port": 8080},
+		"team/web":     map[string]any{"name": "web", "port": float64(80)},
+		"team/tags":    []any{"a", "b"},
+		"team/empty/x": map[string]any{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %#v, want %#v", got, want)
+	}
+
+	got, err = Load(dir, Dir(DirList))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	wantList := []any{
+		map[string]any{"name": "api", "port": 8080, "_file": "api.yaml"},
+		map[string]any{"_file": "team/empty/x.toml"},
+		[]any{"a", "b"},
+		map[string]any{"name": "web", "port": float64(80), "_file": "team/web.json"},
+	}
+	if !reflect.DeepEqual(got, wantList) {
+		t.Errorf("Load() = %#v, want %#v", got, wantList)
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"duplicate key", map[string]string{"api.json": "{}", "api.yaml": "a: 1"}, `api.json and api.yaml both have the key "api"`},
+		{"invalid file", map[string]string{"ok.yaml": "a: 1", "sub/bad.json": "{"}, "sub/bad.json: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeDataDir(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDirLayout(t *testing.T) {
+	for name, want := range map[string]DirLayout{"object": DirObject, "list": DirList} {
+		if got, err := ParseDirLayout(name); err != nil || got != want {
+			t.Errorf("ParseDirLayout(%q) = %v, %v", name, got, err)
+		}
+	}
+	if _, err := ParseDirLayout("tree"); err == nil {
+		t.Error("ParseDirLayout() should return error for unknown layout")
+	}
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"sort"
+	"strings"
+)
+
+// EnvSeparator separates nesting levels in environment variable names.
+const EnvSeparator = "__"
+
+// FromEnv returns the variables in environ, in os.Environ form, whose
+// names start with prefix. The prefix is stripped, names are lowercased and
+// EnvSeparator nests them, so APP_DB__HOST becomes {"db": {"host": ...}}.
+// Values are strings. When a name is both a value and a parent, as with
+// APP_DB and APP_DB__HOST, the nested values win.
+func FromEnv(environ []string, prefix string) map[string]any {
+	vars := make(map[string]string)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		vars[strings.ToLower(name[len(prefix):])] = value
+	}
+
+	// Sorted, so a parent comes before its nested values
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]any)
+	for _, name := range names {
+		parts := strings.Split(name, EnvSeparator)
+		m := result
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := m[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				m[part] = child
+			}
+			m = child
+		}
+		last := parts[len(parts)-1]
+		if _, ok := m[last].(map[string]any); !ok {
+			m[last] = vars[name]
+		}
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	environ := []string{
+		"APP_NAME=svc",
+		"APP_DB__HOST=db",
+		"APP_DB__PORT=5432",
+		"APP_DB__POOL__SIZE=10",
+		"APP_LOG=debug=1",
+		"APP_=ignored",
+		"OTHER_NAME=ignored",
+		"app_lower=ignored",
+	}
+
+	got := FromEnv(environ, "APP_")
+	want := map[string]any{
+		"name": "svc",
+		"db": map[string]any{
+			"host": "db",
+			"port": "5432",
+			"pool": map[string]any{"size": "10"},
+		},
+		"log": "debug=1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestFromEnv_ParentAndNested(t *testing.T) {
+	got := FromEnv([]string{"APP_DB__HOST=db", "APP_DB=x"}, "APP_")
+	want := map[string]any{"db": map[string]any{"host": "db"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromEnv() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	content := []byte(`
+name = "render"
+version = 3
+ratio = 0.5
+released = 2024-01-02
+built = 2024-01-02T03:04:05Z
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+
+[[bin]]
+name = "a"
+
+[[bin]]
+name = "b"
+`)
+
+	got, err := Parse(content, "toml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]any{
+		"name":     "render",
+		"version":  3,
+		"ratio":    0.5,
+		"released": "2024-01-02",
+		"built":    "2024-01-02T03:04:05Z",
+		"dependencies": map[string]any{
+			"serde": map[string]any{"version": "1.0", "features": []any{"derive"}},
+		},
+		"bin": []any{
+			map[string]any{"name": "a"},
+			map[string]any{"name": "b"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	if _, err := Parse([]byte("name = "), "toml"); err == nil {
+		t.Error("Parse() should return error for invalid TOML")
+	}
+}
+
+func TestParseHCL(t *testing.T) {
+	content := []byte(`
+region   = "eu-west-1"
+replicas = 3
+enabled  = true
+zones    = ["a", "b"]
+tags = {
+  team = "platform"
+}
+`)
+
+	got, err := Parse(content, "hcl")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]any{
+		"region":   "eu-west-1",
+		"replicas": float64(3),
+		"enabled":  true,
+		"zones":    []any{"a", "b"},
+		"tags":     map[string]any{"team": "platform"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	for _, invalid := range []string{"region = ", `resource "x" "y" {}`, "region = var.region"} {
+		if _, err := Parse([]byte(invalid), "hcl"); err == nil {
+			t.Errorf("Parse(%q) should return error", invalid)
+		}
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	content := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 maven-4.0.0.xsd">
+  <!-- comment -->
+  <artifactId>app</artifactId>
+  <packaging/>
+  <modules>
+    <module>api</module>
+    <module>server</module>
+  </modules>
+  <properties>
+    <java.version>21</java.version>
+  </properties>
+  <dependency scope="test">junit</dependency>
+</project>`)
+
+	got, err := Parse(content, "xml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := map[string]any{
+		"project": map[string]any{
+			"@schemaLocation": "http://maven.apache.org/POM/4.0.0 maven-4.0.0.xsd",
+			"artifactId":      "app",
+			"packaging":       "",
+			"modules":         map[string]any{"module": []any{"api", "server"}},
+			"properties":      map[string]any{"java.version": "21"},
+			"dependency":      map[string]any{"@scope": "test", "#text": "junit"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	for _, invalid := range []string{"", "<a>", "<a/><b/>", "<a></b>"} {
+		if _, err := Parse([]byte(invalid), "xml"); err == nil {
+			t.Errorf("Parse(%q) should return error", invalid)
+		}
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	tests := map[string]string{
+		` {"a": 1}`:    "json",
+		"[1]":          "json",
+		"<?xml ?><a/>": "xml",
+		"a: 1":         "yaml",
+		"":             "yaml",
+	}
+	for content, want := range tests {
+		if got := sniffFormat([]byte(content)); got != want {
+			t.Errorf("sniffFormat(%q) = %q, want %q", content, got, want)
+		}
+	}
+}
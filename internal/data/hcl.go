@@ -0,0 +1,46 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// parseHCL parses HCL attributes, as in a Terraform .tfvars file, into the
+// same shapes as JSON. Blocks, variables and function calls are not
+// supported.
+func parseHCL(content []byte) (any, error) {
+	file, diags := hclsyntax.ParseConfig(content, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse HCL: %w", diags)
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse HCL: %w", diags)
+	}
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse HCL: %w", diags)
+		}
+		values[name] = v
+	}
+
+	// cty's JSON form has the same shapes as parsed JSON
+	encoded, err := ctyjson.SimpleJSONValue{Value: cty.ObjectVal(values)}.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert HCL: %w", err)
+	}
+	var result any
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return nil, fmt.Errorf("failed to convert HCL: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Lines decodes JSON Lines one value at a time, so a large file never has
+// to be held in memory. Blank lines are skipped.
+type Lines struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewLines creates a decoder reading JSON Lines from r.
+func NewLines(r io.Reader) *Lines {
+	return &Lines{r: bufio.NewReader(r)}
+}
+
+// Next returns the next value and its line number, or io.EOF after the last
+// value.
+func (l *Lines) Next() (any, int, error) {
+	for {
+		content, err := l.r.ReadBytes('\n')
+		if len(content) == 0 && errors.Is(err, io.EOF) {
+			return nil, l.line, io.EOF
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, l.line, fmt.Errorf("failed to read data: %w", err)
+		}
+		l.line++
+
+		content = bytes.TrimSpace(content)
+		if len(content) == 0 {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(content, &v); err != nil {
+			return nil, l.line, fmt.Errorf("failed to parse JSON Lines: line %d: %w", l.line, err)
+		}
+		return v, l.line, nil
+	}
+}
+
+// parseJSONLines parses JSON Lines into a list with one item per line.
+func parseJSONLines(content []byte) (any, error) {
+	items := make([]any, 0)
+	lines := NewLines(bytes.NewReader(content))
+	for {
+		v, _, err := lines.Next()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	lines := NewLines(strings.NewReader("{\"a\": 1}\n\n  [2]\r\n\"three\""))
+
+	want := []struct {
+		value any
+		line  int
+	}{
+		{map[string]any{"a": float64(1)}, 1},
+		{[]any{float64(2)}, 3},
+		{"three", 4},
+	}
+	for _, w := range want {
+		v, line, err := lines.Next()
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if !reflect.DeepEqual(v, w.value) || line != w.line {
+			t.Errorf("Next() = %#v, %d; want %#v, %d", v, line, w.value, w.line)
+		}
+	}
+	if _, _, err := lines.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestLines_Invalid(t *testing.T) {
+	lines := NewLines(strings.NewReader("{}\n{\"a\":\n"))
+	if _, _, err := lines.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	_, _, err := lines.Next()
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Next() error = %v, want error on line 2", err)
+	}
+}
+
+func TestParseJSONLines(t *testing.T) {
+	got, err := Parse([]byte("{\"n\": 1}\n{\"n\": 2}\n"), "jsonl")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	got, err = Parse(nil, "jsonl")
+	if err != nil || !reflect.DeepEqual(got, []any{}) {
+		t.Errorf("Parse() of empty input = %#v, %v; want empty list", got, err)
+	}
+}
@@ -1,7 +1,9 @@
-// Package data provides JSON and YAML data loading functionality.
+// Package data provides JSON, JSON Lines, YAML, TOML, HCL, XML, CSV and
+// TSV data loading functionality.
 package data
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,10 +13,18 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// Stdin is the data source name that reads standard input.
+const Stdin = "-"
+
 // Load reads data from a file and returns it as a generic interface.
-// It automatically detects the format based on file extension.
-func Load(path string) (any, error) {
-	format, err := detectFormat(path)
+// It automatically detects the format based on file extension. A directory
+// combines the data files under it as selected by Dir.
+func Load(path string, opts ...Option) (any, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return loadDir(path, opts...)
+	}
+
+	format, err := DetectFormat(path)
 	if err != nil {
 		return nil, err
 	}
@@ -25,21 +35,50 @@ func Load(path string) (any, error) {
 	}
 	defer func() { _ = f.Close() }()
 
-	return LoadReader(f, format)
+	return LoadReader(f, format, opts...)
 }
 
 // LoadReader reads data from a reader in the specified format.
-func LoadReader(r io.Reader, format string) (any, error) {
+// An empty format detects JSON, XML or YAML from the content.
+func LoadReader(r io.Reader, format string, opts ...Option) (any, error) {
 	content, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read data: %w", err)
 	}
 
-	return Parse(content, format)
+	if format == "" {
+		format = sniffFormat(content)
+	}
+	return Parse(content, format, opts...)
 }
 
-// Parse parses data from bytes in the specified format.
-func Parse(content []byte, format string) (any, error) {
+// sniffFormat guesses the format of content without a file name: JSON if
+// it starts with an object or array, XML if it starts with a tag, YAML
+// otherwise.
+func sniffFormat(content []byte) string {
+	trimmed := bytes.TrimLeft(content, " \t\r\n")
+	if len(trimmed) == 0 {
+		return "yaml"
+	}
+	switch trimmed[0] {
+	case '{', '[':
+		return "json"
+	case '<':
+		return "xml"
+	default:
+		return "yaml"
+	}
+}
+
+// Parse parses data from bytes in the specified format: json, jsonl, yaml
+// (or yml), toml, hcl (or tfvars), xml, csv or tsv. Every format produces the
+// same shapes as JSON: map[string]any, []any and scalars.
+func Parse(content []byte, format string, opts ...Option) (any, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var data any
 
 	switch format {
@@ -47,12 +86,24 @@ func Parse(content []byte, format string) (any, error) {
 		if err := json.Unmarshal(content, &data); err != nil {
 			return nil, fmt.Errorf("failed to parse JSON: %w", err)
 		}
+	case "jsonl":
+		return parseJSONLines(content)
 	case "yaml", "yml":
 		if err := yaml.Unmarshal(content, &data); err != nil {
 			return nil, fmt.Errorf("failed to parse YAML: %w", err)
 		}
 		// Convert YAML maps to string-keyed maps for consistency with JSON
 		data = normalizeYAML(data)
+	case "toml":
+		return parseTOML(content)
+	case "hcl", "tfvars":
+		return parseHCL(content)
+	case "xml":
+		return parseXML(content)
+	case "csv":
+		return parseCSV(content, ',', o.csv)
+	case "tsv":
+		return parseCSV(content, '\t', o.csv)
 	default:
 		return nil, fmt.Errorf("unsupported data format: %s", format)
 	}
@@ -60,19 +111,31 @@ func Parse(content []byte, format string) (any, error) {
 	return data, nil
 }
 
-// detectFormat determines the data format from the file extension.
+// DetectFormat determines the data format from the file extension.
 // Returns an error for unrecognized file extensions.
-func detectFormat(path string) (string, error) {
+func DetectFormat(path string) (string, error) {
 	lower := strings.ToLower(path)
 	switch {
 	case strings.HasSuffix(lower, ".json"):
 		return "json", nil
+	case strings.HasSuffix(lower, ".jsonl"), strings.HasSuffix(lower, ".ndjson"):
+		return "jsonl", nil
 	case strings.HasSuffix(lower, ".yaml"):
 		return "yaml", nil
 	case strings.HasSuffix(lower, ".yml"):
 		return "yaml", nil
+	case strings.HasSuffix(lower, ".toml"):
+		return "toml", nil
+	case strings.HasSuffix(lower, ".tfvars"), strings.HasSuffix(lower, ".hcl"):
+		return "hcl", nil
+	case strings.HasSuffix(lower, ".xml"):
+		return "xml", nil
+	case strings.HasSuffix(lower, ".csv"):
+		return "csv", nil
+	case strings.HasSuffix(lower, ".tsv"):
+		return "tsv", nil
 	default:
-		return "", fmt.Errorf("unsupported file extension for %q: expected .json, .yaml, or .yml", path)
+		return "", fmt.Errorf("unsupported file extension for %q: expected .json, .jsonl, .ndjson, .yaml, .yml, .toml, .tfvars, .hcl, .xml, .csv or .tsv", path)
 	}
 }
 
@@ -116,7 +116,7 @@ items:
 
 	t.Run("unsupported format", func(t *testing.T) {
 		content := []byte(`test`)
-		_, err := Parse(content, "xml")
+		_, err := Parse(content, "ini")
 		if err == nil {
 			t.Error("Parse() should return error for unsupported format")
 		}
@@ -140,6 +140,18 @@ func TestLoadReader(t *testing.T) {
 		}
 	})
 
+	t.Run("detect format from content", func(t *testing.T) {
+		for _, content := range []string{`  {"key": "value"}`, "key: value\n"} {
+			result, err := LoadReader(strings.NewReader(content), "")
+			if err != nil {
+				t.Fatalf("LoadReader(%q) error = %v", content, err)
+			}
+			if m, ok := result.(map[string]any); !ok || m["key"] != "value" {
+				t.Errorf("LoadReader(%q) = %v, want key=value", content, result)
+			}
+		}
+	})
+
 	t.Run("load YAML from reader", func(t *testing.T) {
 		reader := strings.NewReader("key: value")
 		result, err := LoadReader(reader, "yaml")
@@ -247,27 +259,35 @@ func TestDetectFormat(t *testing.T) {
 		{"file.YAML", "yaml"},
 		{"file.yml", "yaml"},
 		{"file.YML", "yaml"},
+		{"Cargo.toml", "toml"},
+		{"prod.tfvars", "hcl"},
+		{"vars.hcl", "hcl"},
+		{"pom.xml", "xml"},
+		{"people.csv", "csv"},
+		{"events.jsonl", "jsonl"},
+		{"events.ndjson", "jsonl"},
+		{"people.TSV", "tsv"},
 	}
 
 	for _, tt := range validTests {
 		t.Run(tt.path, func(t *testing.T) {
-			result, err := detectFormat(tt.path)
+			result, err := DetectFormat(tt.path)
 			if err != nil {
-				t.Fatalf("detectFormat(%q) unexpected error: %v", tt.path, err)
+				t.Fatalf("DetectFormat(%q) unexpected error: %v", tt.path, err)
 			}
 			if result != tt.expected {
-				t.Errorf("detectFormat(%q) = %q, want %q", tt.path, result, tt.expected)
+				t.Errorf("DetectFormat(%q) = %q, want %q", tt.path, result, tt.expected)
 			}
 		})
 	}
 
 	// Test unsupported extensions return errors
-	unsupportedTests := []string{"file.txt", "file", "file.xml"}
+	unsupportedTests := []string{"file.txt", "file", "file.ini"}
 	for _, path := range unsupportedTests {
 		t.Run("unsupported_"+path, func(t *testing.T) {
-			_, err := detectFormat(path)
+			_, err := DetectFormat(path)
 			if err == nil {
-				t.Errorf("detectFormat(%q) should return error for unsupported extension", path)
+				t.Errorf("DetectFormat(%q) should return error for unsupported extension", path)
 			}
 		})
 	}
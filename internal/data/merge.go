@@ -0,0 +1,141 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ListStrategy selects how Merge combines a list with a list overriding it.
+type ListStrategy int
+
+const (
+	// ListReplace uses the overriding list.
+	ListReplace ListStrategy = iota
+	// ListAppend appends the overriding list's items.
+	ListAppend
+	// ListMergeByKey merges objects with the same key field and appends
+	// the rest.
+	ListMergeByKey
+)
+
+// ParseListStrategy parses a list strategy name: replace, append or
+// merge-by-key.
+func ParseListStrategy(name string) (ListStrategy, error) {
+	switch name {
+	case "replace":
+		return ListReplace, nil
+	case "append":
+		return ListAppend, nil
+	case "merge-by-key":
+		return ListMergeByKey, nil
+	default:
+		return 0, fmt.Errorf("unknown list merge strategy %q (expected replace, append or merge-by-key)", name)
+	}
+}
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	Lists ListStrategy
+	Key   string // Field identifying list items for ListMergeByKey
+}
+
+// Merge deep-merges override into base and returns the result; neither
+// argument is modified. Objects are merged key by key, lists are combined
+// according to opts, and any other value in override replaces the one in
+// base.
+func Merge(base, override any, opts MergeOptions) any {
+	switch o := override.(type) {
+	case map[string]any:
+		b, ok := base.(map[string]any)
+		if !ok {
+			return override
+		}
+		result := make(map[string]any, len(b)+len(o))
+		for k, v := range b {
+			result[k] = v
+		}
+		for k, v := range o {
+			if existing, ok := result[k]; ok {
+				result[k] = Merge(existing, v, opts)
+			} else {
+				result[k] = v
+			}
+		}
+		return result
+	case []any:
+		b, ok := base.([]any)
+		if !ok {
+			return override
+		}
+		return mergeLists(b, o, opts)
+	default:
+		return override
+	}
+}
+
+// mergeLists combines two lists according to opts.Lists.
+func mergeLists(base, override []any, opts MergeOptions) []any {
+	switch opts.Lists {
+	case ListAppend:
+		return append(append([]any(nil), base...), override...)
+	case ListMergeByKey:
+		if !keyed(base, opts.Key) || !keyed(override, opts.Key) {
+			return override
+		}
+		result := append([]any(nil), base...)
+		for _, item := range override {
+			key := item.(map[string]any)[opts.Key]
+			i := indexByKey(result, opts.Key, key)
+			if i < 0 {
+				result = append(result, item)
+			} else {
+				result[i] = Merge(result[i], item, opts)
+			}
+		}
+		return result
+	default:
+		return override
+	}
+}
+
+// keyed reports whether every item in list is an object with the key field.
+func keyed(list []any, key string) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// indexByKey returns the index of the object in list whose key field equals
+// value, or -1. Numbers match by value, so an id from YAML (an int) matches
+// the same id from JSON (a float64).
+func indexByKey(list []any, key string, value any) int {
+	value = normalizeKey(value)
+	for i, item := range list {
+		if reflect.DeepEqual(normalizeKey(item.(map[string]any)[key]), value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeKey converts a numeric key value to float64 and returns any other
+// value unchanged.
+func normalizeKey(v any) any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return v
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := map[string]any{
+		"name": "app",
+		"db":   map[string]any{"host": "localhost", "port": 5432},
+		"tags": []any{"a", "b"},
+		"services": []any{
+			map[string]any{"name": "api", "replicas": 1},
+			map[string]any{"name": "web", "replicas": 1},
+		},
+	}
+	override := map[string]any{
+		"db":   map[string]any{"host": "prod-db"},
+		"tags": []any{"c"},
+		"services": []any{
+			map[string]any{"name": "web", "replicas": 3},
+			map[string]any{"name": "worker", "replicas": 2},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     MergeOptions
+		tags     []any
+		services []any
+	}{
+		{
+			name:     "replace",
+			opts:     MergeOptions{Lists: ListReplace},
+			tags:     []any{"c"},
+			services: override["services"].([]any),
+		},
+		{
+			name: "append",
+			opts: MergeOptions{Lists: ListAppend},
+			tags: []any{"a", "b", "c"},
+			services: []any{
+				map[string]any{"name": "api", "replicas": 1},
+				map[string]any{"name": "web", "replicas": 1},
+				map[string]any{"name": "web", "replicas": 3},
+				map[string]any{"name": "worker", "replicas": 2},
+			},
+		},
+		{
+			name: "merge by key",
+			opts: MergeOptions{Lists: ListMergeByKey, Key: "name"},
+			// Scalar lists have no keys, so they are replaced
+			tags: []any{"c"},
+			services: []any{
+				map[string]any{"name": "api", "replicas": 1},
+				map[string]any{"name": "web", "replicas": 3},
+				map[string]any{"name": "worker", "replicas": 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(base, override, tt.opts).(map[string]any)
+
+			wantDB := map[string]any{"host": "prod-db", "port": 5432}
+			if !reflect.DeepEqual(got["db"], wantDB) {
+				t.Errorf("db = %v, want %v", got["db"], wantDB)
+			}
+			if got["name"] != "app" {
+				t.Errorf("name = %v, want app", got["name"])
+			}
+			if !reflect.DeepEqual(got["tags"], tt.tags) {
+				t.Errorf("tags = %v, want %v", got["tags"], tt.tags)
+			}
+			if !reflect.DeepEqual(got["services"], tt.services) {
+				t.Errorf("services = %v, want %v", got["services"], tt.services)
+			}
+		})
+	}
+
+	// The inputs are left alone
+	if base["db"].(map[string]any)["host"] != "localhost" || len(base["services"].([]any)) != 2 {
+		t.Error("Merge modified base")
+	}
+}
+
+func TestMerge_TypeMismatch(t *testing.T) {
+	got := Merge(map[string]any{"a": map[string]any{"b": 1}}, map[string]any{"a": "flat"}, MergeOptions{})
+	if !reflect.DeepEqual(got, map[string]any{"a": "flat"}) {
+		t.Errorf("Merge() = %v, want override to replace", got)
+	}
+}
+
+func TestMerge_ByNumericKeyAcrossFormats(t *testing.T) {
+	base, err := Parse([]byte("services:\n  - id: 1\n    replicas: 1\n  - id: 2\n    replicas: 1\n"), "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	override, err := Parse([]byte(`{"services": [{"id": 2, "replicas": 3}, {"id": 3, "replicas": 1}]}`), "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Merge(base, override, MergeOptions{Lists: ListMergeByKey, Key: "id"})
+	services := got.(map[string]any)["services"].([]any)
+	if len(services) != 3 {
+		t.Fatalf("services = %v, want 3 items with id 2 merged", services)
+	}
+	if replicas := services[1].(map[string]any)["replicas"]; replicas != float64(3) {
+		t.Errorf("services[1].replicas = %v, want 3 from the JSON override", replicas)
+	}
+}
+
+func TestParseListStrategy(t *testing.T) {
+	for name, want := range map[string]ListStrategy{"replace": ListReplace, "append": ListAppend, "merge-by-key": ListMergeByKey} {
+		got, err := ParseListStrategy(name)
+		if err != nil || got != want {
+			t.Errorf("ParseListStrategy(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseListStrategy("concat"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
@@ -3,6 +3,9 @@ package data
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/itchyny/gojq"
 )
@@ -54,3 +57,66 @@ func QueryAll(data any, expression string) ([]any, error) {
 
 	return results, nil
 }
+
+// QueryPaths executes path(expression) against data and returns the location
+// of each result as a jq path (e.g. ".users[2]"), in the same order as
+// QueryAll. It fails for expressions that do not produce paths, such as
+// ".items | map(.name)[]".
+func QueryPaths(data any, expression string) ([]string, error) {
+	query, err := gojq.Parse("path(" + expression + ")")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query expression: %w", err)
+	}
+
+	var paths []string
+	iter := query.Run(data)
+
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+
+		if err, isErr := result.(error); isErr {
+			return nil, fmt.Errorf("query execution error: %w", err)
+		}
+
+		path, ok := result.([]any)
+		if !ok {
+			return nil, fmt.Errorf("query execution error: unexpected path %v", result)
+		}
+		paths = append(paths, FormatPath(path))
+	}
+
+	return paths, nil
+}
+
+// identifierPattern matches object keys that can be written as .key in jq.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FormatPath formats a jq path array (as produced by jq's path()) as a jq
+// expression, e.g. ["users", 2, "first name"] becomes .users[2]["first name"].
+func FormatPath(path []any) string {
+	if len(path) == 0 {
+		return "."
+	}
+
+	var sb strings.Builder
+	for i, p := range path {
+		if s, ok := p.(string); ok && identifierPattern.MatchString(s) {
+			sb.WriteString("." + s)
+			continue
+		}
+
+		// A leading bracket needs a dot: .[0], .["a b"]
+		if i == 0 {
+			sb.WriteString(".")
+		}
+		if s, ok := p.(string); ok {
+			sb.WriteString("[" + strconv.Quote(s) + "]")
+		} else {
+			sb.WriteString(fmt.Sprintf("[%v]", p))
+		}
+	}
+	return sb.String()
+}
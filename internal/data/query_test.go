@@ -163,3 +163,59 @@ func TestQueryAll(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryPaths(t *testing.T) {
+	data := map[string]any{
+		"users": []any{
+			map[string]any{"name": "alice", "active": true},
+			map[string]any{"name": "bob", "active": false},
+			map[string]any{"name": "carol", "active": true},
+		},
+		"by name": map[string]any{"x": 1},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name:       "array iteration",
+			expression: ".users[]",
+			want:       []string{".users[0]", ".users[1]", ".users[2]"},
+		},
+		{
+			name:       "select keeps original indices",
+			expression: ".users[] | select(.active)",
+			want:       []string{".users[0]", ".users[2]"},
+		},
+		{
+			name:       "quoted keys",
+			expression: `.["by name"].x`,
+			want:       []string{`.["by name"].x`},
+		},
+		{
+			name:       "identity",
+			expression: ".",
+			want:       []string{"."},
+		},
+		{
+			name:       "non-path expression",
+			expression: ".users | map(.name)[]",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := QueryPaths(data, tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
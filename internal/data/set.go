@@ -0,0 +1,198 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// segment is one step of a Set path: a map key or a list index.
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Set returns a copy of d with value at path; d is not modified. The path
+// is a dotted list of keys with optional list indexes, such as
+// "services[2].port". A backslash escapes a '.' or '[' in a key. Missing
+// maps are created, values of another type on the path are replaced, and
+// an index may be at most the list's length, which appends.
+func Set(d any, path string, value any) (any, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return set(d, segs, value, "")
+}
+
+// set sets value at segs below node, copying every map and list on the
+// way. at is the path of node, for error messages.
+func set(node any, segs []segment, value any, at string) (any, error) {
+	if len(segs) == 0 {
+		return value, nil
+	}
+
+	s := segs[0]
+	if !s.isIndex {
+		m, _ := node.(map[string]any)
+		result := make(map[string]any, len(m)+1)
+		for k, v := range m {
+			result[k] = v
+		}
+		child, err := set(m[s.key], segs[1:], value, joinKey(at, s.key))
+		if err != nil {
+			return nil, err
+		}
+		result[s.key] = child
+		return result, nil
+	}
+
+	list, _ := node.([]any)
+	if s.index > len(list) {
+		return nil, fmt.Errorf("index %d out of range at %s: list has %d item(s)", s.index, displayPath(at), len(list))
+	}
+	result := make([]any, len(list), len(list)+1)
+	copy(result, list)
+	if s.index == len(list) {
+		result = append(result, nil)
+	}
+	child, err := set(result[s.index], segs[1:], value, fmt.Sprintf("%s[%d]", at, s.index))
+	if err != nil {
+		return nil, err
+	}
+	result[s.index] = child
+	return result, nil
+}
+
+// Lookup returns the value at path in d, in the syntax of Set, and whether
+// it is there.
+func Lookup(d any, path string) (any, bool, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	node := d
+	for _, s := range segs {
+		if s.isIndex {
+			list, ok := node.([]any)
+			if !ok || s.index >= len(list) {
+				return nil, false, nil
+			}
+			node = list[s.index]
+			continue
+		}
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		if node, ok = m[s.key]; !ok {
+			return nil, false, nil
+		}
+	}
+	return node, true, nil
+}
+
+// joinKey appends key to the path at.
+func joinKey(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+// displayPath names a path in messages, using "the root" for the empty path.
+func displayPath(at string) string {
+	if at == "" {
+		return "the root"
+	}
+	return at
+}
+
+// parsePath splits a Set path into segments.
+func parsePath(path string) ([]segment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var segs []segment
+	var key strings.Builder
+	hasKey := false   // key holds the current key
+	afterDot := false // the last character was a separator
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\' && i+1 < len(path):
+			i++
+			key.WriteByte(path[i])
+			hasKey = true
+		case c == '.':
+			if !hasKey && (i == 0 || afterDot) {
+				return nil, fmt.Errorf("invalid path %q: empty key", path)
+			}
+			if hasKey {
+				segs = append(segs, segment{key: key.String()})
+				key.Reset()
+				hasKey = false
+			}
+			afterDot = true
+			continue
+		case c == '[':
+			if afterDot {
+				return nil, fmt.Errorf("invalid path %q: index must follow a key", path)
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			n, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid path %q: bad index %q", path, path[i+1:i+end])
+			}
+			if hasKey {
+				segs = append(segs, segment{key: key.String()})
+				key.Reset()
+				hasKey = false
+			}
+			segs = append(segs, segment{index: n, isIndex: true})
+			i += end
+			if i+1 < len(path) && path[i+1] != '.' && path[i+1] != '[' {
+				return nil, fmt.Errorf("invalid path %q: expected . or [ after ]", path)
+			}
+		default:
+			key.WriteByte(c)
+			hasKey = true
+		}
+		afterDot = false
+	}
+
+	if afterDot {
+		return nil, fmt.Errorf("invalid path %q: empty key", path)
+	}
+	if hasKey {
+		segs = append(segs, segment{key: key.String()})
+	}
+	return segs, nil
+}
+
+// ParseValue parses a value the way Parse would parse it as a YAML scalar,
+// so numbers, booleans and null are typed. Anything that is not a single
+// scalar, such as "a: b" or "[1, 2]", is returned as the string itself.
+func ParseValue(s string) any {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil || len(doc.Content) != 1 {
+		return s
+	}
+	node := doc.Content[0]
+	if node.Kind != yaml.ScalarNode {
+		return s
+	}
+
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return s
+	}
+	return v
+}
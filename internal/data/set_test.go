@@ -0,0 +1,164 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	base := func() any {
+		return map[string]any{
+			"name": "app",
+			"services": []any{
+				map[string]any{"name": "api", "port": 80},
+				map[string]any{"name": "web", "port": 80},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		check func(t *testing.T, got any)
+	}{
+		{
+			name: "top-level key", path: "name", value: "svc",
+			check: func(t *testing.T, got any) {
+				if v := got.(map[string]any)["name"]; v != "svc" {
+					t.Errorf("name = %v", v)
+				}
+			},
+		},
+		{
+			name: "new nested key", path: "db.primary.host", value: "localhost",
+			check: func(t *testing.T, got any) {
+				db := got.(map[string]any)["db"].(map[string]any)
+				if v := db["primary"].(map[string]any)["host"]; v != "localhost" {
+					t.Errorf("db.primary.host = %v", v)
+				}
+			},
+		},
+		{
+			name: "indexed path", path: "services[1].port", value: 8080,
+			check: func(t *testing.T, got any) {
+				svc := got.(map[string]any)["services"].([]any)
+				if v := svc[1].(map[string]any)["port"]; v != 8080 {
+					t.Errorf("services[1].port = %v", v)
+				}
+				if v := svc[1].(map[string]any)["name"]; v != "web" {
+					t.Errorf("services[1].name = %v", v)
+				}
+			},
+		},
+		{
+			name: "append to list", path: "services[2].name", value: "worker",
+			check: func(t *testing.T, got any) {
+				svc := got.(map[string]any)["services"].([]any)
+				if len(svc) != 3 || svc[2].(map[string]any)["name"] != "worker" {
+					t.Errorf("services = %v", svc)
+				}
+			},
+		},
+		{
+			name: "replace scalar with map", path: "name.first", value: "a",
+			check: func(t *testing.T, got any) {
+				if v := got.(map[string]any)["name"]; !reflect.DeepEqual(v, map[string]any{"first": "a"}) {
+					t.Errorf("name = %v", v)
+				}
+			},
+		},
+		{
+			name: "escaped dot", path: `annotations.app\.io/name`, value: "x",
+			check: func(t *testing.T, got any) {
+				if v := got.(map[string]any)["annotations"].(map[string]any)["app.io/name"]; v != "x" {
+					t.Errorf("annotations = %v", got.(map[string]any)["annotations"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := base()
+			got, err := Set(d, tt.path, tt.value)
+			if err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			tt.check(t, got)
+			if !reflect.DeepEqual(d, base()) {
+				t.Errorf("Set modified its argument: %v", d)
+			}
+		})
+	}
+}
+
+func TestSet_RootList(t *testing.T) {
+	got, err := Set([]any{"a"}, "[0]", "b")
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []any{"b"}) {
+		t.Errorf("Set() = %v", got)
+	}
+}
+
+func TestSet_Errors(t *testing.T) {
+	d := map[string]any{"list": []any{1}}
+	for _, path := range []string{"", ".a", "a.", "a..b", "a.[0]", "a[x]", "a[-1]", "a[0", "a[0]b", "list[2]"} {
+		if _, err := Set(d, path, 1); err == nil {
+			t.Errorf("Set(%q) succeeded, want error", path)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	d := map[string]any{"db": map[string]any{"port": 5432, "host": nil}, "list": []any{"a"}}
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"db.port", 5432, true},
+		{"db.host", nil, true},
+		{"list[0]", "a", true},
+		{"db.name", nil, false},
+		{"list[1]", nil, false},
+		{"db.port.x", nil, false},
+	}
+	for _, tt := range tests {
+		got, found, err := Lookup(d, tt.path)
+		if err != nil {
+			t.Fatalf("Lookup(%q) failed: %v", tt.path, err)
+		}
+		if found != tt.found || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q) = %v, %v, want %v, %v", tt.path, got, found, tt.want, tt.found)
+		}
+	}
+	if _, _, err := Lookup(d, "a..b"); err == nil {
+		t.Error("Lookup(\"a..b\") succeeded, want error")
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+	}{
+		{"8080", 8080},
+		{"1.5", 1.5},
+		{"true", true},
+		{"null", nil},
+		{"hello", "hello"},
+		{"", ""},
+		{"'8080'", "8080"},
+		{"a: b", "a: b"},
+		{"[1, 2]", "[1, 2]"},
+		{"# not a comment", "# not a comment"},
+	}
+	for _, tt := range tests {
+		if got := ParseValue(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseValue(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
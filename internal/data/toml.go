@@ -0,0 +1,65 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML parses a TOML document into the same shapes as JSON. Integers
+// become int, as they do for YAML, and dates and times become strings.
+func parseTOML(content []byte) (any, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	return normalizeTOML(doc), nil
+}
+
+// normalizeTOML converts the values the TOML decoder produces to JSON
+// shapes.
+func normalizeTOML(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			result[k] = normalizeTOML(v)
+		}
+		return result
+	case []map[string]any:
+		// Arrays of tables
+		result := make([]any, len(val))
+		for i, v := range val {
+			result[i] = normalizeTOML(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, v := range val {
+			result[i] = normalizeTOML(v)
+		}
+		return result
+	case int64:
+		return int(val)
+	case time.Time:
+		return formatTOMLTime(val)
+	default:
+		return v
+	}
+}
+
+// formatTOMLTime formats a TOML date or time the way it is written. The
+// decoder marks local dates and times with zones of these names.
+func formatTOMLTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
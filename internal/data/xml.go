@@ -0,0 +1,102 @@
+package data
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlElement is an element being parsed.
+type xmlElement struct {
+	name   string
+	fields map[string]any // attributes and child elements
+	text   strings.Builder
+}
+
+// parseXML parses an XML document into the same shapes as JSON:
+//
+//   - The document is an object whose only key is the root element's name.
+//   - An element with only text is that text, trimmed; an empty element
+//     is "".
+//   - Any other element is an object. Attributes are keys prefixed with
+//     "@", child elements are keyed by name and non-blank text is "#text".
+//   - A child element that occurs more than once is a list in document
+//     order; one that occurs once is not a list.
+//   - Namespace prefixes are dropped, and xmlns attributes are skipped.
+//   - All values are strings.
+func parseXML(content []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+
+	var stack []*xmlElement
+	var root map[string]any
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, fmt.Errorf("failed to parse XML: more than one root element")
+			}
+			e := &xmlElement{name: t.Name.Local, fields: make(map[string]any)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				e.fields["@"+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, e)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				root = map[string]any{e.name: e.value()}
+				continue
+			}
+			addXMLChild(stack[len(stack)-1].fields, e.name, e.value())
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("failed to parse XML: no root element")
+	}
+	return root, nil
+}
+
+// value returns the JSON shape of a parsed element.
+func (e *xmlElement) value() any {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.fields) == 0 {
+		return text
+	}
+	if text != "" {
+		e.fields["#text"] = text
+	}
+	return e.fields
+}
+
+// addXMLChild adds a child element's value to its parent's fields, making
+// a list when the name repeats. Element values are never lists, so a list
+// always means a repeated element.
+func addXMLChild(fields map[string]any, name string, value any) {
+	switch existing := fields[name].(type) {
+	case nil:
+		fields[name] = value
+	case []any:
+		fields[name] = append(existing, value)
+	default:
+		fields[name] = []any{existing, value}
+	}
+}
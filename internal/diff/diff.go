@@ -0,0 +1,245 @@
+// Package diff compares file contents: it produces unified diffs and
+// three-way merges.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// Unified returns a unified diff turning old into updated, labelled with
+// oldName and newName. It returns "" if the contents are identical, and a
+// one-line notice instead of a diff if either side looks binary.
+func Unified(oldName, newName string, old, updated []byte) string {
+	if bytes.Equal(old, updated) {
+		return ""
+	}
+	if isBinary(old) || isBinary(updated) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName)
+	}
+
+	a := SplitLines(string(old))
+	b := SplitLines(string(updated))
+	edits := editScript(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(edits) {
+		writeHunk(&sb, edits[h.start:h.end], a, b)
+	}
+	return sb.String()
+}
+
+// op is the kind of an edit.
+type op byte
+
+const (
+	opEqual  op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+// edit is one line of an edit script. a and b are the line's position in
+// the old and new content; for inserts a is where the line goes in old, and
+// for deletes b is where the line was in new.
+type edit struct {
+	op   op
+	a, b int
+}
+
+// SplitLines splits s into lines, each keeping its trailing newline. The
+// last line has none if s doesn't end with one.
+func SplitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// isBinary reports whether content contains a NUL byte.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// editScript computes a shortest edit script from a to b using the linear
+// space variant of Myers' algorithm: each step finds the middle snake of an
+// optimal path and splits the problem there, so memory stays proportional
+// to the number of lines rather than to the lines times the edits.
+func editScript(a, b []string) []edit {
+	size := len(a) + len(b) + 3
+	s := &script{a: a, b: b, fwd: make([]int, size), bwd: make([]int, size)}
+	s.compare(0, len(a), 0, len(b))
+	return s.edits
+}
+
+// script accumulates an edit script, in order, as editScript builds it.
+type script struct {
+	a, b     []string
+	fwd, bwd []int // Furthest x reached on each diagonal, searching forwards and backwards
+	edits    []edit
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (s *script) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && s.a[aLo] == s.b[bLo] {
+		s.edits = append(s.edits, edit{op: opEqual, a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	aEnd := aHi
+	for aLo < aHi && bLo < bHi && s.a[aHi-1] == s.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			s.edits = append(s.edits, edit{op: opInsert, a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			s.edits = append(s.edits, edit{op: opDelete, a: x, b: bLo})
+		}
+	default:
+		// Neither half of an optimal path through the split is empty
+		x, y := s.middleSnake(aLo, aHi, bLo, bHi)
+		s.compare(aLo, x, bLo, y)
+		s.compare(x, aHi, y, bHi)
+	}
+
+	for x, y := aHi, bHi; x < aEnd; x, y = x+1, y+1 {
+		s.edits = append(s.edits, edit{op: opEqual, a: x, b: y})
+	}
+}
+
+// middleSnake searches from both ends of a[aLo:aHi] and b[bLo:bHi] at once
+// until the paths overlap, and returns the start of the snake where they
+// meet, which lies on a shortest edit script. Positions in the search are
+// relative to aLo and bLo; backwards, they count from aHi and bHi.
+func (s *script) middleSnake(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	offset := (len(s.fwd) - 1) / 2
+	s.fwd[offset+1] = 0
+	s.bwd[offset+1] = 0
+
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && s.fwd[offset+k-1] < s.fwd[offset+k+1]) {
+				x = s.fwd[offset+k+1]
+			} else {
+				x = s.fwd[offset+k-1] + 1
+			}
+			x0, y0 := x, x-k
+			for y := x - k; x < n && y < m && s.a[aLo+x] == s.b[bLo+y]; y++ {
+				x++
+			}
+			s.fwd[offset+k] = x
+
+			// With an odd delta, the paths can first meet going forwards
+			if back := delta - k; delta%2 != 0 && back >= -(d-1) && back <= d-1 && x+s.bwd[offset+back] >= n {
+				return aLo + x0, bLo + y0
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && s.bwd[offset+k-1] < s.bwd[offset+k+1]) {
+				x = s.bwd[offset+k+1]
+			} else {
+				x = s.bwd[offset+k-1] + 1
+			}
+			for y := x - k; x < n && y < m && s.a[aHi-1-x] == s.b[bHi-1-y]; y++ {
+				x++
+			}
+			s.bwd[offset+k] = x
+
+			// With an even delta, they first meet going backwards
+			if fwd := delta - k; delta%2 == 0 && fwd >= -d && fwd <= d && x+s.fwd[offset+fwd] >= n {
+				return aHi - x, bHi - (x - k)
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
+
+// span is a half-open range of edits.
+type span struct {
+	start, end int
+}
+
+// hunks groups changes in edits into hunks with surrounding context,
+// merging changes whose context would overlap.
+func hunks(edits []edit) []span {
+	var result []span
+	for i := 0; i < len(edits); i++ {
+		if edits[i].op == opEqual {
+			continue
+		}
+
+		start := max(i-context, 0)
+		if n := len(result); n > 0 && start <= result[n-1].end {
+			// Overlaps the previous hunk's trailing context
+			start = result[n-1].start
+			result = result[:n-1]
+		}
+
+		// Extend to the end of this run of changes
+		end := i
+		for end < len(edits) && edits[end].op != opEqual {
+			end++
+		}
+		i = end - 1
+
+		result = append(result, span{start: start, end: min(end+context, len(edits))})
+	}
+	return result
+}
+
+// writeHunk writes one hunk of edits with its header.
+func writeHunk(sb *strings.Builder, edits []edit, a, b []string) {
+	var aCount, bCount int
+	for _, e := range edits {
+		if e.op != opInsert {
+			aCount++
+		}
+		if e.op != opDelete {
+			bCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(edits[0].a, aCount), hunkRange(edits[0].b, bCount))
+
+	for _, e := range edits {
+		var line string
+		if e.op == opInsert {
+			line = b[e.b]
+		} else {
+			line = a[e.a]
+		}
+		sb.WriteByte(byte(e.op))
+		sb.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the 0-based start and line count of one side of a hunk.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		// An empty range names the line before it
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
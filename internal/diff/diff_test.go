@@ -0,0 +1,152 @@
+package diff
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestUnified_Identical(t *testing.T) {
+	if got := Unified("a", "b", []byte("same\n"), []byte("same\n")); got != "" {
+		t.Errorf("Expected empty diff, got %q", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deleted content",
+			old:  "a\n",
+			new:  "",
+			want: "--- old\n+++ new\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name: "merged hunks",
+			old:  "a\n1\n2\n3\nb\n",
+			new:  "A\n1\n2\n3\nB\n",
+			want: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n-a\n+A\n 1\n 2\n 3\n-b\n+B\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("old", "new", []byte(tt.old), []byte(tt.new))
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnified_Binary(t *testing.T) {
+	got := Unified("old.png", "new.png", []byte("\x89PNG\x00a"), []byte("\x89PNG\x00b"))
+	if !strings.HasPrefix(got, "Binary files old.png and new.png differ") {
+		t.Errorf("Unified() = %q", got)
+	}
+}
+
+func TestEditScript_Minimal(t *testing.T) {
+	a := SplitLines("a\nb\nc\na\nb\nb\na\n")
+	b := SplitLines("c\nb\na\nb\na\nc\n")
+
+	changes := 0
+	for _, e := range editScript(a, b) {
+		if e.op != opEqual {
+			changes++
+		}
+	}
+	// The classic Myers example has a shortest edit script of length 5
+	if changes != 5 {
+		t.Errorf("Expected 5 changes, got %d", changes)
+	}
+}
+
+func TestEditScript_Random(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	random := func() []string {
+		lines := make([]string, r.IntN(40))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.IntN(4)))
+		}
+		return lines
+	}
+
+	for range 500 {
+		a, b := random(), random()
+		edits := editScript(a, b)
+
+		// The script turns a into b, visiting every line of each in order
+		var x, y, changes int
+		for _, e := range edits {
+			if e.a != x || e.b != y {
+				t.Fatalf("edit %v out of order at a=%d, b=%d for %q → %q", e, x, y, a, b)
+			}
+			switch e.op {
+			case opEqual:
+				if a[x] != b[y] {
+					t.Fatalf("edit %v keeps unequal lines for %q → %q", e, a, b)
+				}
+				x++
+				y++
+			case opDelete:
+				x++
+				changes++
+			case opInsert:
+				y++
+				changes++
+			}
+		}
+		if x != len(a) || y != len(b) {
+			t.Fatalf("script ends at a=%d, b=%d for %q → %q", x, y, a, b)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("%d changes for %q → %q, want %d", changes, a, b, want)
+		}
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
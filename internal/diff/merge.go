@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+)
+
+// Conflict markers written around regions both sides changed differently.
+const (
+	markerCurrent = "<<<<<<<"
+	markerSep     = "======="
+	markerUpdated = ">>>>>>>"
+)
+
+// Merge performs a line-based three-way merge: it applies to current the
+// changes that turned base into updated. Regions changed differently on
+// both sides are kept as conflicts between markers labelled currentName
+// and updatedName, and their number is returned. Binary content can't hold
+// markers, so a binary conflict returns current unchanged.
+func Merge(base, current, updated []byte, currentName, updatedName string) ([]byte, int) {
+	switch {
+	case bytes.Equal(current, updated), bytes.Equal(base, updated):
+		return current, 0
+	case bytes.Equal(base, current):
+		return updated, 0
+	case isBinary(base) || isBinary(current) || isBinary(updated):
+		return current, 1
+	}
+
+	o := SplitLines(string(base))
+	a := SplitLines(string(current))
+	b := SplitLines(string(updated))
+	ours := changes(editScript(o, a))
+	theirs := changes(editScript(o, b))
+
+	var sb strings.Builder
+	var conflicts int
+	pos := 0               // Base lines before pos are written
+	aDelta, bDelta := 0, 0 // Line offset of each side from base
+	i, j := 0, 0           // Next change on each side
+	for i < len(ours) || j < len(theirs) {
+		// Start a region at the earliest change
+		var lo, hi int
+		if j == len(theirs) || (i < len(ours) && ours[i].baseStart <= theirs[j].baseStart) {
+			lo, hi = ours[i].baseStart, ours[i].baseEnd
+		} else {
+			lo, hi = theirs[j].baseStart, theirs[j].baseEnd
+		}
+
+		// Grow it over every change it touches on either side
+		aStart, bStart := lo+aDelta, lo+bDelta
+		for {
+			if i < len(ours) && ours[i].baseStart <= hi {
+				hi = max(hi, ours[i].baseEnd)
+				aDelta += ours[i].delta()
+				i++
+			} else if j < len(theirs) && theirs[j].baseStart <= hi {
+				hi = max(hi, theirs[j].baseEnd)
+				bDelta += theirs[j].delta()
+				j++
+			} else {
+				break
+			}
+		}
+
+		writeLines(&sb, o[pos:lo])
+		pos = hi
+
+		baseLines := o[lo:hi]
+		aLines := a[aStart : hi+aDelta]
+		bLines := b[bStart : hi+bDelta]
+		switch {
+		case slices.Equal(aLines, baseLines), slices.Equal(aLines, bLines):
+			writeLines(&sb, bLines)
+		case slices.Equal(bLines, baseLines):
+			writeLines(&sb, aLines)
+		default:
+			conflicts++
+			sb.WriteString(markerCurrent + " " + currentName + "\n")
+			writeLines(&sb, aLines)
+			terminate(&sb)
+			sb.WriteString(markerSep + "\n")
+			writeLines(&sb, bLines)
+			terminate(&sb)
+			sb.WriteString(markerUpdated + " " + updatedName + "\n")
+		}
+	}
+	writeLines(&sb, o[pos:])
+
+	return []byte(sb.String()), conflicts
+}
+
+// change is a run of edits replacing base lines [baseStart, baseEnd) with
+// count lines of the other side.
+type change struct {
+	baseStart, baseEnd int
+	count              int
+}
+
+// delta returns how many lines the change adds.
+func (c change) delta() int {
+	return c.count - (c.baseEnd - c.baseStart)
+}
+
+// changes groups an edit script into runs of changed lines.
+func changes(edits []edit) []change {
+	var result []change
+	for i := 0; i < len(edits); i++ {
+		if edits[i].op == opEqual {
+			continue
+		}
+		c := change{baseStart: edits[i].a, baseEnd: edits[i].a}
+		for ; i < len(edits) && edits[i].op != opEqual; i++ {
+			if edits[i].op == opDelete {
+				c.baseEnd++
+			} else {
+				c.count++
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// writeLines writes lines that already carry their newlines.
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
+
+// terminate ends the last line written with a newline if it has none,
+// so a conflict marker starts on its own line.
+func terminate(sb *strings.Builder) {
+	if s := sb.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		sb.WriteByte('\n')
+	}
+}
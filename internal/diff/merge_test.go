@@ -0,0 +1,93 @@
+package diff
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		current   string
+		updated   string
+		want      string
+		conflicts int
+	}{
+		{
+			name:    "only template changed",
+			base:    "a\nb\nc\n",
+			current: "a\nb\nc\n",
+			updated: "a\nB\nc\n",
+			want:    "a\nB\nc\n",
+		},
+		{
+			name:    "only local edits",
+			base:    "a\nb\nc\n",
+			current: "a\nb\nc\nlocal\n",
+			updated: "a\nb\nc\n",
+			want:    "a\nb\nc\nlocal\n",
+		},
+		{
+			name:    "separate changes",
+			base:    "1\n2\n3\n4\n5\n6\n7\n",
+			current: "1\nlocal\n2\n3\n4\n5\n6\n7\n",
+			updated: "1\n2\n3\n4\n5\n6\nSEVEN\n",
+			want:    "1\nlocal\n2\n3\n4\n5\n6\nSEVEN\n",
+		},
+		{
+			name:    "same change on both sides",
+			base:    "a\nb\nc\n",
+			current: "a\nX\nc\nd\n",
+			updated: "a\nX\nc\n",
+			want:    "a\nX\nc\nd\n",
+		},
+		{
+			name:      "conflict",
+			base:      "a\nb\nc\n",
+			current:   "a\nmine\nc\n",
+			updated:   "a\ntheirs\nc\n",
+			want:      "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\nc\n",
+			conflicts: 1,
+		},
+		{
+			name:      "conflict without trailing newline",
+			base:      "a\nb",
+			current:   "a\nmine",
+			updated:   "a\ntheirs",
+			want:      "a\n<<<<<<< current\nmine\n=======\ntheirs\n>>>>>>> template\n",
+			conflicts: 1,
+		},
+		{
+			name:    "deleted by template",
+			base:    "a\nb\nc\nd\ne\n",
+			current: "a\nb\nc\nd\ne\nf\n",
+			updated: "a\nd\ne\n",
+			want:    "a\nd\ne\nf\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge([]byte(tt.base), []byte(tt.current), []byte(tt.updated), "current", "template")
+			if string(got) != tt.want {
+				t.Errorf("Merge() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("conflicts = %d, want %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestMerge_Binary(t *testing.T) {
+	base := []byte("a\x00")
+	current := []byte("b\x00")
+
+	got, conflicts := Merge(base, current, []byte("c\x00"), "current", "template")
+	if string(got) != string(current) || conflicts != 1 {
+		t.Errorf("Merge() = %q, %d; want current and 1 conflict", got, conflicts)
+	}
+
+	got, conflicts = Merge(base, base, []byte("c\x00"), "current", "template")
+	if string(got) != "c\x00" || conflicts != 0 {
+		t.Errorf("Merge() = %q, %d; want updated content", got, conflicts)
+	}
+}
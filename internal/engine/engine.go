@@ -4,56 +4,315 @@ package engine
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/wernerstrydom/render/internal/funcs"
 )
 
 // Engine handles template parsing and execution.
+// It is safe for concurrent use.
 type Engine struct {
-	funcMap template.FuncMap
+	funcMap  template.FuncMap
+	strict   bool // fail on missing map keys instead of printing <no value>
+	allowEnv bool // env reads environment variables
+
+	mu sync.Mutex // guards partials, partialSrc, loads, cache and cacheGen
+
+	// partials is the shared set of named templates that every rendered
+	// template can invoke with {{ template "name" . }}. It is never executed
+	// directly; each render works on a clone so partials stay parseable.
+	partials   *template.Template
+	partialSrc map[string]string       // partial name → source text
+	loads      map[string]*partialLoad // LoadPartialsOnce calls by key
+
+	cache    map[string]*Template // compiled template files keyed by source path
+	cacheGen int                  // bumped whenever the partial set changes
+}
+
+// Template is a parsed template that can be executed many times,
+// including concurrently.
+type Template struct {
+	tmpl *template.Template
+	src  string  // source text, for error snippets
+	eng  *Engine // owner, for partial sources in error snippets
+}
+
+// Execute renders the compiled template with the given data.
+// Execution failures are returned as *Error.
+func (t *Template) Execute(data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", newError(t.tmpl.Name(), err, t.source)
+	}
+	return buf.String(), nil
+}
+
+// IsTrue renders the template as a condition, such as a when: in a control
+// file. It holds unless the result, ignoring surrounding whitespace, is
+// empty, "false", "0" or "<no value>".
+func (t *Template) IsTrue(data any) (bool, error) {
+	result, err := t.Execute(data)
+	if err != nil {
+		return false, err
+	}
+	switch strings.TrimSpace(result) {
+	case "", "false", "0", "<no value>":
+		return false, nil
+	}
+	return true, nil
+}
+
+// source returns the text of the named template file: the compiled template
+// itself or one of the engine's partials.
+func (t *Template) source(name string) string {
+	if name == t.tmpl.Name() {
+		return t.src
+	}
+	return t.eng.partialSource(name)
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// Strict makes every template the engine compiles fail on a missing map key
+// (missingkey=error) instead of silently rendering "<no value>".
+func Strict(strict bool) Option {
+	return func(e *Engine) {
+		e.strict = strict
+	}
+}
+
+// AllowEnv lets templates read environment variables with the env function.
+// Otherwise env fails, so output depends only on templates and data.
+func AllowEnv(allow bool) Option {
+	return func(e *Engine) {
+		e.allowEnv = allow
+	}
 }
 
 // New creates a new template engine with custom functions.
-func New() *Engine {
-	return &Engine{
-		funcMap: funcs.Map(),
+func New(opts ...Option) *Engine {
+	e := &Engine{
+		funcMap:    funcs.Map(),
+		partialSrc: make(map[string]string),
+		cache:      make(map[string]*Template),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.allowEnv {
+		e.funcMap["env"] = funcs.Env
 	}
+	return e
 }
 
-// RenderString renders a template string with the given data.
-func (e *Engine) RenderString(tmpl string, data any) (string, error) {
-	t, err := template.New("template").Funcs(e.funcMap).Parse(tmpl)
+// IsStrict reports whether the engine fails on missing map keys.
+func (e *Engine) IsStrict() bool {
+	return e.strict
+}
+
+// AddPartial parses content into the shared partial set under the given name.
+// Any {{ define }} blocks it contains become available to every template the
+// engine renders afterwards. Adding the same name with identical content again
+// is a no-op, so callers may load the same partials more than once.
+func (e *Engine) AddPartial(name, content string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if src, ok := e.partialSrc[name]; ok && src == content {
+		return nil
+	}
+
+	if e.partials == nil {
+		e.partials = template.New("").Funcs(e.funcMap)
+	}
+
+	if _, err := e.partials.New(name).Parse(content); err != nil {
+		return fmt.Errorf("failed to parse partial: %w", newError(name, err, func(string) string { return content }))
+	}
+	e.partialSrc[name] = content
+
+	// Templates compiled before this partial existed cannot see it
+	clear(e.cache)
+	e.cacheGen++
+
+	return nil
+}
+
+// partialLoad is the outcome of a LoadPartialsOnce call.
+type partialLoad struct {
+	once sync.Once
+	err  error
+}
+
+// LoadPartialsOnce calls load, which adds partials to the engine, the first
+// time it is called with key, such as the directory the partials are read
+// from. Later calls wait for the first to finish and return its error, so
+// code that renders many times can load its partials once.
+func (e *Engine) LoadPartialsOnce(key string, load func() error) error {
+	e.mu.Lock()
+	l, ok := e.loads[key]
+	if !ok {
+		if e.loads == nil {
+			e.loads = make(map[string]*partialLoad)
+		}
+		l = &partialLoad{}
+		e.loads[key] = l
+	}
+	e.mu.Unlock()
+
+	// load adds partials, which takes the lock
+	l.once.Do(func() { l.err = load() })
+	return l.err
+}
+
+// partialSource returns the source text of a partial, or "" if unknown.
+func (e *Engine) partialSource(name string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.partialSrc[name]
+}
+
+// LoadPartials parses every .tmpl file under dir into the shared partial set.
+// Each file is named by its slash-separated path relative to dir.
+func (e *Engine) LoadPartials(dir string) error {
+	info, err := os.Stat(dir)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return fmt.Errorf("failed to access partials directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("partials path is not a directory: %s", dir)
 	}
 
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".tmpl") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read partial %s: %w", relPath, err)
+		}
+
+		return e.AddPartial(filepath.ToSlash(relPath), string(content))
+	})
+}
+
+// newTemplate returns an empty template with the engine's functions and,
+// when partials are loaded, a private copy of the shared partial set.
+func (e *Engine) newTemplate(name string) (*template.Template, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.partials == nil {
+		return template.New(name).Funcs(e.funcMap), nil
 	}
 
-	return buf.String(), nil
+	t, err := e.partials.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone partials: %w", err)
+	}
+	return t.New(name), nil
+}
+
+// Compile parses a template string once so it can be executed many times.
+// The name identifies the template in error messages.
+func (e *Engine) Compile(name, text string) (*Template, error) {
+	t, err := e.newTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err = t.Parse(text)
+	if err != nil {
+		return nil, newError(name, err, func(n string) string {
+			if n == name {
+				return text
+			}
+			return e.partialSource(n)
+		})
+	}
+
+	// Options are per template, so apply to every associated template
+	// (partials and {{ define }} blocks), not just the one being compiled
+	if e.strict {
+		for _, assoc := range t.Templates() {
+			assoc.Option("missingkey=error")
+		}
+	}
+
+	return &Template{tmpl: t, src: text, eng: e}, nil
+}
+
+// CompileFile parses the template file at path under the given name.
+// Compiled files are cached by path, so later calls for the same path
+// return the same handle without reading or parsing the file again.
+func (e *Engine) CompileFile(name, path string) (*Template, error) {
+	e.mu.Lock()
+	t, ok := e.cache[path]
+	gen := e.cacheGen
+	e.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file %s: %w", path, err)
+	}
+
+	t, err = e.Compile(name, string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if e.cacheGen == gen {
+		e.cache[path] = t
+	}
+	e.mu.Unlock()
+
+	return t, nil
+}
+
+// RenderString renders a template string with the given data.
+func (e *Engine) RenderString(tmpl string, data any) (string, error) {
+	t, err := e.Compile("template", tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Execute(data)
 }
 
 // RenderFile renders a template file with the given data.
 func (e *Engine) RenderFile(path string, data any) (string, error) {
-	t, err := template.New("").Funcs(e.funcMap).ParseFiles(path)
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template file %s: %w", path, err)
+		return "", fmt.Errorf("failed to read template file %s: %w", path, err)
 	}
 
-	// ParseFiles creates a template with the base name of the file
-	t = t.Lookup(filepath.Base(path))
-	if t == nil {
-		return "", fmt.Errorf("template not found after parsing: %s", path)
+	t, err := e.Compile(filepath.Base(path), string(content))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
 	}
 
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("failed to execute template %s: %w", path, err)
+	result, err := t.Execute(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
 	}
 
-	return buf.String(), nil
+	return result, nil
 }
@@ -1,8 +1,12 @@
 package engine
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -336,3 +340,364 @@ func containsAt(s, substr string, start int) bool {
 	}
 	return false
 }
+
+func TestAddPartial(t *testing.T) {
+	t.Run("define block is shared", func(t *testing.T) {
+		eng := New()
+		if err := eng.AddPartial("_helpers.tmpl", `{{ define "greeting" }}Hello, {{ .name }}{{ end }}`); err != nil {
+			t.Fatalf("AddPartial() error = %v", err)
+		}
+
+		result, err := eng.RenderString(`{{ template "greeting" . }}!`, map[string]any{"name": "World"})
+		if err != nil {
+			t.Fatalf("RenderString() error = %v", err)
+		}
+		if result != "Hello, World!" {
+			t.Errorf("RenderString() = %q, want %q", result, "Hello, World!")
+		}
+	})
+
+	t.Run("partial is reusable across renders", func(t *testing.T) {
+		eng := New()
+		if err := eng.AddPartial("_helpers.tmpl", `{{ define "name" }}{{ upper .name }}{{ end }}`); err != nil {
+			t.Fatalf("AddPartial() error = %v", err)
+		}
+
+		for _, name := range []string{"a", "b"} {
+			result, err := eng.RenderString(`{{ template "name" . }}`, map[string]any{"name": name})
+			if err != nil {
+				t.Fatalf("RenderString() error = %v", err)
+			}
+			if result != strings.ToUpper(name) {
+				t.Errorf("RenderString() = %q, want %q", result, strings.ToUpper(name))
+			}
+		}
+
+		// Adding more partials after rendering must still work
+		if err := eng.AddPartial("_more.tmpl", `{{ define "more" }}more{{ end }}`); err != nil {
+			t.Fatalf("AddPartial() after render error = %v", err)
+		}
+	})
+
+	t.Run("identical partial is a no-op", func(t *testing.T) {
+		eng := New()
+		content := `{{ define "x" }}x{{ end }}`
+		if err := eng.AddPartial("_x.tmpl", content); err != nil {
+			t.Fatalf("AddPartial() error = %v", err)
+		}
+		if err := eng.AddPartial("_x.tmpl", content); err != nil {
+			t.Fatalf("AddPartial() second call error = %v", err)
+		}
+	})
+
+	t.Run("invalid partial", func(t *testing.T) {
+		eng := New()
+		err := eng.AddPartial("_bad.tmpl", `{{ define "x" }}`)
+		if err == nil {
+			t.Fatal("AddPartial() should return error for invalid template")
+		}
+		if !strings.Contains(err.Error(), "_bad.tmpl") {
+			t.Errorf("error should name the partial: %v", err)
+		}
+	})
+
+	t.Run("undefined template without partials", func(t *testing.T) {
+		eng := New()
+		if _, err := eng.RenderString(`{{ template "missing" . }}`, nil); err == nil {
+			t.Error("RenderString() should fail for undefined template")
+		}
+	})
+}
+
+func TestLoadPartials(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "k8s"), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	files := map[string]string{
+		"labels.tmpl":     `{{ define "labels" }}app: {{ .name }}{{ end }}`,
+		"k8s/annot.tmpl":  `{{ define "annotations" }}team: {{ .team }}{{ end }}`,
+		"README.md":       `{{ define "ignored" }}{{ end }}`,
+		"k8s/footer.tmpl": `-- {{ .name }} --`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	eng := New()
+	if err := eng.LoadPartials(dir); err != nil {
+		t.Fatalf("LoadPartials() error = %v", err)
+	}
+
+	data := map[string]any{"name": "api", "team": "core"}
+	result, err := eng.RenderString(`{{ template "labels" . }}; {{ template "annotations" . }}; {{ template "k8s/footer.tmpl" . }}`, data)
+	if err != nil {
+		t.Fatalf("RenderString() error = %v", err)
+	}
+	want := "app: api; team: core; -- api --"
+	if result != want {
+		t.Errorf("RenderString() = %q, want %q", result, want)
+	}
+
+	if _, err := eng.RenderString(`{{ template "ignored" . }}`, data); err == nil {
+		t.Error("non-.tmpl files should not be loaded as partials")
+	}
+
+	if err := New().LoadPartials(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadPartials() should fail for missing directory")
+	}
+}
+
+func TestLoadPartialsOnce(t *testing.T) {
+	eng := New()
+	calls := 0
+	load := func() error {
+		calls++
+		return eng.AddPartial("header", `{{ define "header" }}# {{ . }}{{ end }}`)
+	}
+
+	for range 3 {
+		if err := eng.LoadPartialsOnce("templates", load); err != nil {
+			t.Fatalf("LoadPartialsOnce() error = %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("load called %d times, want 1", calls)
+	}
+	if result, err := eng.RenderString(`{{ template "header" "Title" }}`, nil); err != nil || result != "# Title" {
+		t.Errorf("RenderString() = %q, %v", result, err)
+	}
+
+	// A failed load reports its error every time
+	bad := func() error { return eng.AddPartial("bad", "{{ .x") }
+	for range 2 {
+		if err := eng.LoadPartialsOnce("other", bad); err == nil {
+			t.Error("LoadPartialsOnce() should return the load error")
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	eng := New()
+
+	tmpl, err := eng.Compile("greeting", "Hello, {{ .name }}!")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	for _, name := range []string{"Alice", "Bob"} {
+		result, err := tmpl.Execute(map[string]any{"name": name})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if want := "Hello, " + name + "!"; result != want {
+			t.Errorf("Execute() = %q, want %q", result, want)
+		}
+	}
+
+	if _, err := eng.Compile("bad", "{{ .name"); err == nil {
+		t.Error("Compile() should return error for invalid template")
+	} else if !strings.Contains(err.Error(), "bad") {
+		t.Errorf("Compile() error should name the template: %v", err)
+	}
+}
+
+func TestIsTrue(t *testing.T) {
+	eng := New()
+	tests := map[string]bool{
+		`{{ .kind }}`:               true,
+		`{{ eq .kind "http" }}`:     true,
+		`{{ eq .kind "grpc" }}`:     false,
+		`{{ .missing }}`:            false,
+		` {{ if .kind }}0{{ end }}`: false,
+		``:                          false,
+	}
+	for text, want := range tests {
+		cond, err := eng.Compile("when", text)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", text, err)
+		}
+		got, err := cond.IsTrue(map[string]any{"kind": "http"})
+		if err != nil {
+			t.Fatalf("IsTrue(%q) error = %v", text, err)
+		}
+		if got != want {
+			t.Errorf("IsTrue(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestCompileFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.tmpl")
+	if err := os.WriteFile(path, []byte("v1 {{ .name }}"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	eng := New()
+	first, err := eng.CompileFile("test.tmpl", path)
+	if err != nil {
+		t.Fatalf("CompileFile() error = %v", err)
+	}
+
+	// Change the file; the cached handle must be returned without re-reading
+	if err := os.WriteFile(path, []byte("v2 {{ .name }}"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	second, err := eng.CompileFile("test.tmpl", path)
+	if err != nil {
+		t.Fatalf("CompileFile() error = %v", err)
+	}
+	if first != second {
+		t.Error("CompileFile() should return the cached template for the same path")
+	}
+
+	// Adding a partial invalidates the cache
+	if err := eng.AddPartial("_p.tmpl", `{{ define "p" }}{{ end }}`); err != nil {
+		t.Fatalf("AddPartial() error = %v", err)
+	}
+	third, err := eng.CompileFile("test.tmpl", path)
+	if err != nil {
+		t.Fatalf("CompileFile() error = %v", err)
+	}
+	result, err := third.Execute(map[string]any{"name": "x"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result != "v2 x" {
+		t.Errorf("Execute() after partial added = %q, want %q", result, "v2 x")
+	}
+
+	if _, err := eng.CompileFile("missing.tmpl", filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("CompileFile() should return error for non-existent file")
+	}
+}
+
+func TestEngineConcurrentUse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "item.tmpl")
+	if err := os.WriteFile(path, []byte(`{{ template "name" . }}`), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	eng := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := eng.AddPartial("_helpers.tmpl", `{{ define "name" }}{{ .name }}{{ end }}`); err != nil {
+				t.Errorf("AddPartial() error = %v", err)
+				return
+			}
+			tmpl, err := eng.CompileFile("item.tmpl", path)
+			if err != nil {
+				t.Errorf("CompileFile() error = %v", err)
+				return
+			}
+			want := fmt.Sprintf("n%d", i)
+			result, err := tmpl.Execute(map[string]any{"name": want})
+			if err != nil {
+				t.Errorf("Execute() error = %v", err)
+				return
+			}
+			if result != want {
+				t.Errorf("Execute() = %q, want %q", result, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestStrict(t *testing.T) {
+	data := map[string]any{"db": map[string]any{"host": "localhost"}}
+
+	t.Run("default renders no value", func(t *testing.T) {
+		eng := New()
+		if eng.IsStrict() {
+			t.Error("IsStrict() = true, want false")
+		}
+		result, err := eng.RenderString("{{ .db.port }}", data)
+		if err != nil {
+			t.Fatalf("RenderString() error = %v", err)
+		}
+		if result != "<no value>" {
+			t.Errorf("RenderString() = %q, want %q", result, "<no value>")
+		}
+	})
+
+	t.Run("strict reports missing key", func(t *testing.T) {
+		eng := New(Strict(true))
+		tmpl, err := eng.Compile("config.yaml.tmpl", "host: {{ .db.host }}\nport: {{ .db.port }}")
+		if err != nil {
+			t.Fatalf("Compile() error = %v", err)
+		}
+
+		_, err = tmpl.Execute(data)
+		var mk *MissingKeyError
+		if !errors.As(err, &mk) {
+			t.Fatalf("Execute() error = %v, want MissingKeyError", err)
+		}
+		if mk.Template != "config.yaml.tmpl" || mk.Line != 2 || mk.Path != ".db.port" || mk.Key != "port" {
+			t.Errorf("MissingKeyError = %+v", mk)
+		}
+		if want := `config.yaml.tmpl:2:13: missing key "port" in .db.port`; mk.Error() != want {
+			t.Errorf("Error() = %q, want %q", mk.Error(), want)
+		}
+	})
+
+	t.Run("strict applies to partials and define blocks", func(t *testing.T) {
+		eng := New(Strict(true))
+		if err := eng.AddPartial("_helpers.tmpl", `{{ define "port" }}{{ .db.port }}{{ end }}`); err != nil {
+			t.Fatalf("AddPartial() error = %v", err)
+		}
+
+		_, err := eng.RenderString(`{{ template "port" . }}`, data)
+		var mk *MissingKeyError
+		if !errors.As(err, &mk) {
+			t.Fatalf("partial: error = %v, want MissingKeyError", err)
+		}
+		if mk.Template != "_helpers.tmpl" {
+			t.Errorf("Template = %q, want %q", mk.Template, "_helpers.tmpl")
+		}
+
+		_, err = eng.RenderString(`{{ define "local" }}{{ .missing }}{{ end }}{{ template "local" . }}`, data)
+		if !errors.As(err, &mk) {
+			t.Fatalf("define block: error = %v, want MissingKeyError", err)
+		}
+	})
+
+	t.Run("strict allows present keys", func(t *testing.T) {
+		eng := New(Strict(true))
+		result, err := eng.RenderString("{{ .db.host }}", data)
+		if err != nil {
+			t.Fatalf("RenderString() error = %v", err)
+		}
+		if result != "localhost" {
+			t.Errorf("RenderString() = %q", result)
+		}
+	})
+}
+
+func TestAllowEnv(t *testing.T) {
+	t.Setenv("RENDER_TEST_ENV", "value")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		_, err := New().RenderString(`{{ env "RENDER_TEST_ENV" }}`, nil)
+		if err == nil || !strings.Contains(err.Error(), "disabled") {
+			t.Errorf("RenderString() error = %v, want env disabled", err)
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		result, err := New(AllowEnv(true)).RenderString(`{{ env "RENDER_TEST_ENV" }}|{{ env "RENDER_TEST_UNSET" }}`, nil)
+		if err != nil {
+			t.Fatalf("RenderString() error = %v", err)
+		}
+		if result != "value|" {
+			t.Errorf("RenderString() = %q, want %q", result, "value|")
+		}
+	})
+}
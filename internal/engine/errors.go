@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Error describes a template parse or execution failure and where it happened.
+type Error struct {
+	Template string // Template name (usually the relative source path)
+	Line     int    // 1-based line, or 0 if unknown
+	Column   int    // 1-based column, or 0 if unknown
+	Message  string // Failure description without the location prefix
+	Snippet  string // Surrounding source lines with a caret at the column
+	err      error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.Template, e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.Template, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Template, e.Message)
+	}
+}
+
+// Unwrap returns the underlying error: a *MissingKeyError for missing keys in
+// strict mode, otherwise the text/template error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// MissingKeyError reports a map key that a strict template referenced but the
+// data did not contain.
+type MissingKeyError struct {
+	Template string // Template name (usually the relative source path)
+	Line     int    // 1-based line of the failing action
+	Column   int    // 1-based column of the failing action
+	Path     string // Field chain being evaluated, e.g. ".db.host"
+	Key      string // The key that was missing, e.g. "host"
+	err      error
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: missing key %q in %s", e.Template, e.Line, e.Column, e.Key, e.Path)
+}
+
+// Unwrap returns the underlying text/template error.
+func (e *MissingKeyError) Unwrap() error {
+	return e.err
+}
+
+// locationPattern matches the "template: name:line[:col]: message" prefix
+// text/template puts on parse and execution errors. Columns are 0-based.
+var locationPattern = regexp.MustCompile(`^template: (.+?):(\d+)(?::(\d+))?: (.*)$`)
+
+// executingPattern matches the "executing "name" at <.a.b>: " part of an
+// execution error message.
+var executingPattern = regexp.MustCompile(`^executing ".*?" at <(.*?)>: (.*)$`)
+
+// missingKeyPattern matches the missingkey=error failure message.
+var missingKeyPattern = regexp.MustCompile(`^map has no entry for key "(.*)"$`)
+
+// newError converts a text/template error into an *Error. The template name
+// reported by text/template may differ from name when the failure is inside a
+// partial; source returns the text of whichever template failed.
+func newError(name string, err error, source func(name string) string) *Error {
+	msg := err.Error()
+	var execErr template.ExecError
+	if errors.As(err, &execErr) {
+		msg = execErr.Err.Error()
+	}
+
+	e := &Error{Template: name, Message: msg, err: err}
+
+	m := locationPattern.FindStringSubmatch(msg)
+	if m == nil {
+		e.Message = strings.TrimPrefix(msg, "template: ")
+		return e
+	}
+
+	e.Template = m[1]
+	e.Line, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		col, _ := strconv.Atoi(m[3])
+		e.Column = col + 1
+	}
+	e.Message = m[4]
+
+	if x := executingPattern.FindStringSubmatch(e.Message); x != nil {
+		e.Message = fmt.Sprintf("at <%s>: %s", x[1], x[2])
+		if k := missingKeyPattern.FindStringSubmatch(x[2]); k != nil {
+			e.Message = fmt.Sprintf("missing key %q in %s", k[1], x[1])
+			e.err = &MissingKeyError{
+				Template: e.Template,
+				Line:     e.Line,
+				Column:   e.Column,
+				Path:     x[1],
+				Key:      k[1],
+				err:      err,
+			}
+		}
+	}
+
+	e.Snippet = snippet(source(e.Template), e.Line, e.Column)
+	return e
+}
+
+// snippetContext is the number of lines shown before and after the failing line.
+const snippetContext = 2
+
+// snippet renders the lines around line with a caret under column, e.g.
+//
+//	  1 | name: {{ .name }}
+//	> 2 | port: {{ .server.port }}
+//	    |               ^
+//
+// Returns "" if line is outside src.
+func snippet(src string, line, column int) string {
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	from := max(1, line-snippetContext)
+	to := min(len(lines), line+snippetContext)
+	width := len(strconv.Itoa(to))
+
+	var sb strings.Builder
+	for n := from; n <= to; n++ {
+		text := strings.TrimRight(lines[n-1], "\r")
+		marker := "  "
+		if n == line {
+			marker = "> "
+		}
+		fmt.Fprintf(&sb, "%s%*d | %s\n", marker, width, n, text)
+
+		if n == line && column > 0 && column <= len(text)+1 {
+			// Keep tabs so the caret lines up with the source
+			pad := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return '\t'
+				}
+				return ' '
+			}, text[:column-1])
+			fmt.Fprintf(&sb, "  %*s | %s^\n", width, "", pad)
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestError_Execution(t *testing.T) {
+	eng := New()
+	tmpl, err := eng.Compile("deploy/service.yaml.tmpl", "kind: Service\nname: {{ .name }}\nport: {{ add .port }}\nend\n")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	_, err = tmpl.Execute(map[string]any{"name": "api", "port": 80})
+	var tmplErr *Error
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("Execute() error = %T %v, want *Error", err, err)
+	}
+
+	if tmplErr.Template != "deploy/service.yaml.tmpl" {
+		t.Errorf("Template = %q", tmplErr.Template)
+	}
+	if tmplErr.Line != 3 {
+		t.Errorf("Line = %d, want 3", tmplErr.Line)
+	}
+	if tmplErr.Column == 0 {
+		t.Error("Column should be set for execution errors")
+	}
+	if !strings.HasPrefix(tmplErr.Error(), "deploy/service.yaml.tmpl:3:") {
+		t.Errorf("Error() = %q", tmplErr.Error())
+	}
+	if strings.Contains(tmplErr.Message, "executing") {
+		t.Errorf("Message should not repeat text/template's prefix: %q", tmplErr.Message)
+	}
+	for _, want := range []string{"  1 | kind: Service", "> 3 | port: {{ add .port }}", "  4 | end", "^"} {
+		if !strings.Contains(tmplErr.Snippet, want) {
+			t.Errorf("Snippet missing %q:\n%s", want, tmplErr.Snippet)
+		}
+	}
+}
+
+func TestError_Parse(t *testing.T) {
+	eng := New()
+	_, err := eng.Compile("config.tmpl", "a\nb {{ .name\nc")
+
+	var tmplErr *Error
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("Compile() error = %T %v, want *Error", err, err)
+	}
+	if tmplErr.Template != "config.tmpl" || tmplErr.Line == 0 {
+		t.Errorf("Error = %+v", tmplErr)
+	}
+	if !strings.Contains(tmplErr.Snippet, "b {{ .name") {
+		t.Errorf("Snippet should include the failing line:\n%s", tmplErr.Snippet)
+	}
+}
+
+func TestError_InPartial(t *testing.T) {
+	eng := New(Strict(true))
+	if err := eng.AddPartial("_helpers.tmpl", "{{ define \"labels\" }}\napp: {{ .app }}\n{{ end }}"); err != nil {
+		t.Fatalf("AddPartial() error = %v", err)
+	}
+
+	_, err := eng.RenderString(`{{ template "labels" . }}`, map[string]any{})
+
+	var tmplErr *Error
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("error = %T %v, want *Error", err, err)
+	}
+	if tmplErr.Template != "_helpers.tmpl" || tmplErr.Line != 2 {
+		t.Errorf("Error = %+v, want _helpers.tmpl line 2", tmplErr)
+	}
+	if !strings.Contains(tmplErr.Snippet, "> 2 | app: {{ .app }}") {
+		t.Errorf("Snippet should show partial source:\n%s", tmplErr.Snippet)
+	}
+
+	var mk *MissingKeyError
+	if !errors.As(err, &mk) || mk.Key != "app" {
+		t.Errorf("error should unwrap to MissingKeyError for app, got %v", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	src := "one\ntwo\nthree\nfour\nfive\nsix\n"
+
+	got := snippet(src, 4, 3)
+	want := "  2 | two\n  3 | three\n> 4 | four\n    |   ^\n  5 | five\n  6 | six"
+	if got != want {
+		t.Errorf("snippet() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := snippet(src, 1, 0); got != "> 1 | one\n  2 | two\n  3 | three" {
+		t.Errorf("snippet() at first line =\n%s", got)
+	}
+
+	if got := snippet("\tx {{ .a }}", 1, 4); got != "> 1 | \tx {{ .a }}\n    | \t  ^" {
+		t.Errorf("snippet() should keep tabs:\n%q", got)
+	}
+
+	if got := snippet(src, 99, 1); got != "" {
+		t.Errorf("snippet() out of range = %q, want empty", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"math"
+	"os"
 	"reflect"
 	"regexp"
 	"slices"
@@ -168,6 +169,9 @@ func Map() template.FuncMap {
 		"count":      count,
 		"countWords": countWords,
 		"countLines": countLines,
+
+		// Environment (disabled unless the engine allows it)
+		"env": envDisabled,
 	}
 }
 
@@ -1264,3 +1268,16 @@ func countLines(s string) int {
 	}
 	return strings.Count(s, "\n") + 1
 }
+
+// Environment functions
+
+// Env returns the value of the environment variable name, or "" if it is
+// unset. Map binds env to a function that fails instead, so templates are
+// hermetic unless the engine explicitly allows Env.
+func Env(name string) string {
+	return os.Getenv(name)
+}
+
+func envDisabled(name string) (string, error) {
+	return "", fmt.Errorf("env %q: reading environment variables is disabled (allow it with --allow-env)", name)
+}
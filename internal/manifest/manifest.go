@@ -0,0 +1,250 @@
+// Package manifest records the files a render produced, so later runs can
+// find and prune files that are no longer generated.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/output"
+)
+
+// FileName is the name of the manifest file in the output directory.
+const FileName = ".render-manifest.json"
+
+// version is the manifest format version.
+const version = 1
+
+// hashPrefix names the hash algorithm in the manifest file.
+const hashPrefix = "sha256:"
+
+// Manifest maps each generated file to the hash of the content render wrote.
+type Manifest struct {
+	Version int
+	Files   map[string][sha256.Size]byte // Slash-separated path relative to the output directory → SHA-256 of the content
+}
+
+// file is the manifest as written, with hashes formatted by FormatHash.
+type file struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+// New creates an empty manifest.
+func New() *Manifest {
+	return &Manifest{Version: version, Files: make(map[string][sha256.Size]byte)}
+}
+
+// MarshalJSON encodes the manifest with formatted hashes.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	f := file{Version: m.Version, Files: make(map[string]string, len(m.Files))}
+	for path, sum := range m.Files {
+		f.Files[path] = FormatHash(sum)
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalJSON decodes a manifest written by MarshalJSON.
+func (m *Manifest) UnmarshalJSON(content []byte) error {
+	var f file
+	if err := json.Unmarshal(content, &f); err != nil {
+		return err
+	}
+	m.Version = f.Version
+	m.Files = make(map[string][sha256.Size]byte, len(f.Files))
+	for path, hash := range f.Files {
+		sum, err := parseHash(hash)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		m.Files[path] = sum
+	}
+	return nil
+}
+
+// Load reads the manifest in dir. A missing manifest yields an empty one.
+func Load(dir string) (*Manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m := New()
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filepath.Join(dir, FileName), err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string][sha256.Size]byte)
+	}
+
+	// Prune deletes these paths, so they must stay inside dir
+	for path := range m.Files {
+		if !filepath.IsLocal(filepath.FromSlash(path)) {
+			return nil, fmt.Errorf("manifest %s lists a path outside the output directory: %s", filepath.Join(dir, FileName), path)
+		}
+	}
+	return m, nil
+}
+
+// Save writes the manifest to dir, creating dir if needed. The manifest is
+// written to a temporary file and renamed into place, so a failed save
+// leaves the previous manifest intact.
+func (m *Manifest) Save(dir string) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := output.WriteFileAtomic(filepath.Join(dir, FileName), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Add records a generated file. path is relative to the output directory.
+func (m *Manifest) Add(path string, content []byte) {
+	m.Files[filepath.ToSlash(path)] = sha256.Sum256(content)
+}
+
+// Hash returns the content hash as written in manifests.
+func Hash(content []byte) string {
+	return FormatHash(sha256.Sum256(content))
+}
+
+// FormatHash formats a SHA-256 sum as written in manifests.
+func FormatHash(sum [sha256.Size]byte) string {
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+// parseHash parses a hash formatted by FormatHash.
+func parseHash(hash string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	digest, ok := strings.CutPrefix(hash, hashPrefix)
+	if !ok {
+		return sum, fmt.Errorf("unsupported hash %q", hash)
+	}
+	if len(digest) != hex.EncodedLen(sha256.Size) {
+		return sum, fmt.Errorf("invalid hash %q", hash)
+	}
+	if _, err := hex.Decode(sum[:], []byte(digest)); err != nil {
+		return sum, fmt.Errorf("invalid hash %q", hash)
+	}
+	return sum, nil
+}
+
+// Stale returns the paths recorded in m but not in next, sorted.
+func (m *Manifest) Stale(next *Manifest) []string {
+	var stale []string
+	for path := range m.Files {
+		if _, ok := next.Files[path]; !ok {
+			stale = append(stale, path)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// fileState describes a stale file on disk.
+type fileState int
+
+const (
+	stateGone      fileState = iota // Already deleted
+	stateGenerated                  // Content still matches the manifest
+	stateModified                   // Edited since it was generated
+)
+
+// state compares the file at path under dir with its recorded hash.
+func (m *Manifest) state(dir, path string) (fileState, error) {
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		return stateGone, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if sha256.Sum256(content) != m.Files[path] {
+		return stateModified, nil
+	}
+	return stateGenerated, nil
+}
+
+// PruneResult lists the stale files found by Prune.
+type PruneResult struct {
+	Removed  []string // Deleted (or, in a dry run, would be deleted)
+	Modified []string // Kept because they were edited since they were generated
+}
+
+// Remover deletes files and empty directories for Prune. A transactional
+// output.Writer journals the deletions, so they can be rolled back.
+type Remover interface {
+	Remove(path string) error
+}
+
+// Prune deletes the files under dir that prev recorded but next does not,
+// along with any directories left empty, using rm. Files whose content no
+// longer matches prev are hand-edited and kept. With a nil rm, nothing is
+// deleted. Paths in the result are slash-separated and relative to dir.
+func Prune(dir string, prev, next *Manifest, rm Remover) (*PruneResult, error) {
+	result := &PruneResult{}
+	for _, path := range prev.Stale(next) {
+		state, err := prev.state(dir, path)
+		if err != nil {
+			return result, err
+		}
+
+		switch state {
+		case stateModified:
+			result.Modified = append(result.Modified, path)
+		case stateGenerated:
+			result.Removed = append(result.Removed, path)
+			if rm == nil {
+				continue
+			}
+			full := filepath.Join(dir, filepath.FromSlash(path))
+			if err := rm.Remove(full); err != nil {
+				return result, err
+			}
+			removeEmptyParents(rm, dir, filepath.Dir(full))
+		}
+	}
+	return result, nil
+}
+
+// Retain copies into next the stale entries of prev whose files are still
+// on disk unchanged, so a later Prune can still remove them.
+func Retain(dir string, prev, next *Manifest) error {
+	for _, path := range prev.Stale(next) {
+		state, err := prev.state(dir, path)
+		if err != nil {
+			return err
+		}
+		if state == stateGenerated {
+			next.Files[path] = prev.Files[path]
+		}
+	}
+	return nil
+}
+
+// removeEmptyParents removes empty directories from dir up to, but not
+// including, root.
+func removeEmptyParents(rm Remover, root, dir string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		// Remove fails on non-empty directories, which ends the walk
+		if err := rm.Remove(dir); err != nil {
+			return
+		}
+	}
+}
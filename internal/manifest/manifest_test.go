@@ -0,0 +1,158 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	m, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(m.Files) != 0 {
+		t.Errorf("Expected empty manifest, got %v", m.Files)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+
+	m := New()
+	m.Add(filepath.Join("sub", "a.txt"), []byte("a"))
+	if err := m.Save(dir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Files["sub/a.txt"] != sha256.Sum256([]byte("a")) {
+		t.Errorf("Files = %v", loaded.Files)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"sub/a.txt": "` + Hash([]byte("a")) + `"`; !strings.Contains(string(content), want) {
+		t.Errorf("manifest = %s, want it to contain %s", content, want)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, FileName, "{not json")
+
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected error for invalid manifest")
+	}
+}
+
+func TestLoad_InvalidHash(t *testing.T) {
+	for _, hash := range []string{"md5:00", "sha256:zz", "sha256:" + strings.Repeat("0", 66)} {
+		dir := t.TempDir()
+		writeFile(t, dir, FileName, `{"version": 1, "files": {"a.txt": "`+hash+`"}}`)
+
+		if _, err := Load(dir); err == nil {
+			t.Errorf("Expected error for hash %q", hash)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "keep.txt", "keep")
+	writeFile(t, dir, "old/gone.txt", "gone")
+	writeFile(t, dir, "edited.txt", "edited by hand")
+
+	prev := New()
+	prev.Add("keep.txt", []byte("keep"))
+	prev.Add("old/gone.txt", []byte("gone"))
+	prev.Add("edited.txt", []byte("generated"))
+	prev.Add("deleted.txt", []byte("deleted"))
+
+	next := New()
+	next.Add("keep.txt", []byte("keep"))
+
+	// A dry run reports without deleting
+	result, err := Prune(dir, prev, next, nil)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if !slices.Equal(result.Removed, []string{"old/gone.txt"}) {
+		t.Errorf("Removed = %v", result.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old", "gone.txt")); err != nil {
+		t.Error("Dry run should not delete files")
+	}
+
+	result, err = Prune(dir, prev, next, osRemover{})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if !slices.Equal(result.Removed, []string{"old/gone.txt"}) {
+		t.Errorf("Removed = %v", result.Removed)
+	}
+	if !slices.Equal(result.Modified, []string{"edited.txt"}) {
+		t.Errorf("Modified = %v", result.Modified)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Error("Expected emptied directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "edited.txt")); err != nil {
+		t.Error("Hand-edited file should be kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "keep.txt")); err != nil {
+		t.Error("Planned file should be kept")
+	}
+}
+
+func TestRetain(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "stale.txt", "stale")
+	writeFile(t, dir, "edited.txt", "edited by hand")
+
+	prev := New()
+	prev.Add("stale.txt", []byte("stale"))
+	prev.Add("edited.txt", []byte("generated"))
+	prev.Add("deleted.txt", []byte("deleted"))
+
+	next := New()
+	if err := Retain(dir, prev, next); err != nil {
+		t.Fatalf("Retain failed: %v", err)
+	}
+
+	var paths []string
+	for path := range next.Files {
+		paths = append(paths, path)
+	}
+	if !slices.Equal(paths, []string{"stale.txt"}) {
+		t.Errorf("Retained %v, want only unchanged files still on disk", paths)
+	}
+}
+
+// Helper functions
+
+// osRemover deletes files directly.
+type osRemover struct{}
+
+func (osRemover) Remove(path string) error {
+	return os.Remove(path)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Transactional makes the writer journal its changes: every file it replaces
+// is backed up and every file and directory it creates is recorded, so that
+// Rollback can restore the output tree after a failure. Call Commit once all
+// writes succeeded to discard the backups.
+func Transactional() Option {
+	return func(w *Writer) {
+		w.transactional = true
+	}
+}
+
+// change is a journaled write or removal.
+type change struct {
+	path   string
+	backup string // Copy of the replaced or removed file; "" if the file was created
+}
+
+// mkdirAll creates dir and any missing parents, journaling the directories
+// it creates.
+func (w *Writer) mkdirAll(dir string) error {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || d == filepath.Dir(d) {
+			break
+		}
+		missing = append(missing, d)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	if w.transactional {
+		// Outermost first, so rollback can remove them in reverse
+		for i := len(missing) - 1; i >= 0; i-- {
+			w.createdDirs = append(w.createdDirs, missing[i])
+		}
+	}
+	return nil
+}
+
+// writeFile atomically replaces path with content. The content is written
+// to a temporary file in the same directory and renamed into place, so the
+// file is never seen half-written. An existing file keeps its permissions.
+func (w *Writer) writeFile(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := w.mkdirAll(dir); err != nil {
+		return err
+	}
+
+	var backup string
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		if w.transactional {
+			if backup, err = w.backupFile(path, perm); err != nil {
+				return err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+
+	if err := WriteFileAtomic(path, content, perm); err != nil {
+		if backup != "" {
+			_ = os.Remove(backup)
+		}
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+
+	if w.transactional {
+		w.journal = append(w.journal, change{path: path, backup: backup})
+	}
+	return nil
+}
+
+// Remove deletes the file or empty directory at path. In transactional
+// mode a file is moved aside to a backup instead, so Rollback can restore
+// it, and directories are removed on Commit, once the backups beside their
+// files are gone, if they are empty by then.
+func (w *Writer) Remove(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	switch {
+	case !w.transactional:
+		err = os.Remove(path)
+	case info.IsDir():
+		w.removedDirs = append(w.removedDirs, path)
+	default:
+		var backup string
+		if backup, err = moveAside(path); err == nil {
+			w.journal = append(w.journal, change{path: path, backup: backup})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// moveAside renames the file at path to a backup next to it and returns
+// the backup's path.
+func moveAside(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".backup-*")
+	if err != nil {
+		return "", err
+	}
+	backup := f.Name()
+	_ = f.Close()
+
+	if err := os.Rename(path, backup); err != nil {
+		_ = os.Remove(backup)
+		return "", err
+	}
+	return backup, nil
+}
+
+// backupFile copies the file at path next to it and returns the copy's path.
+func (w *Writer) backupFile(path string, perm os.FileMode) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".backup-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	backup := f.Name()
+	_ = f.Close()
+
+	if err := WriteFileAtomic(backup, content, perm); err != nil {
+		_ = os.Remove(backup)
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, nil
+}
+
+// WriteFileAtomic writes content to a temporary file next to path and
+// renames it over path, so readers see either the old file or the complete
+// new one, and a failed write leaves the old file intact.
+func WriteFileAtomic(path string, content []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// Commit ends a transaction, deleting the backups of replaced and removed
+// files, then the removed directories that are empty.
+// It is a no-op unless the writer is transactional.
+func (w *Writer) Commit() error {
+	var errs []error
+	for _, c := range w.journal {
+		if c.backup != "" {
+			if err := os.Remove(c.backup); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove backup %s: %w", c.backup, err))
+			}
+		}
+	}
+	for _, dir := range w.removedDirs {
+		// Fails harmlessly if the directory isn't empty
+		_ = os.Remove(dir)
+	}
+	w.journal = nil
+	w.createdDirs = nil
+	w.removedDirs = nil
+	return errors.Join(errs...)
+}
+
+// Rollback undoes every change since the transaction began, newest first:
+// replaced and removed files are restored from their backups, and created
+// files and directories are removed. It is a no-op unless the writer is
+// transactional.
+func (w *Writer) Rollback() error {
+	var errs []error
+	for i := len(w.journal) - 1; i >= 0; i-- {
+		c := w.journal[i]
+		if c.backup != "" {
+			if err := os.Rename(c.backup, c.path); err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", c.path, err))
+			}
+			continue
+		}
+		if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", c.path, err))
+		}
+	}
+	for i := len(w.createdDirs) - 1; i >= 0; i-- {
+		// Fails harmlessly if something else was put in the directory
+		_ = os.Remove(w.createdDirs[i])
+	}
+	w.journal = nil
+	w.createdDirs = nil
+	w.removedDirs = nil
+	return errors.Join(errs...)
+}
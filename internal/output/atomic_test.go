@@ -0,0 +1,184 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite_Atomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.sh")
+
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(true).WriteWithPerm(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteWithPerm failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("Content = %q, want %q", got, "new")
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Mode = %v, want existing mode 0755", info.Mode().Perm())
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only script.sh in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("Content = %q, want %q", got, "new")
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Mode = %v, want 0644", info.Mode().Perm())
+	}
+
+	// A failed write leaves no temporary file behind
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "a.json"), []byte("x"), 0644); err == nil {
+		t.Error("Expected error for a missing directory")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only state.json in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestTransactional_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	replaced := filepath.Join(dir, "a.txt")
+	created := filepath.Join(dir, "sub", "deep", "b.txt")
+
+	if err := os.WriteFile(replaced, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(true, Transactional())
+	if err := w.WriteString(replaced, "new"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.WriteString(created, "new"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if err := w.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(replaced)
+	if string(got) != "old" {
+		t.Errorf("a.txt = %q, want restored %q", got, "old")
+	}
+	if Exists(filepath.Join(dir, "sub")) {
+		t.Error("Created directories should be removed")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only a.txt in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestTransactional_Remove(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	path := filepath.Join(sub, "a.txt")
+
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(true, Transactional())
+	if err := w.Remove(path); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := w.Remove(sub); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if Exists(path) {
+		t.Fatal("a.txt should be removed")
+	}
+
+	if err := w.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "old" {
+		t.Errorf("a.txt = %q, want restored %q", got, "old")
+	}
+	entries, _ := os.ReadDir(sub)
+	if len(entries) != 1 {
+		t.Errorf("Expected only a.txt in %s, got %d entries", sub, len(entries))
+	}
+
+	// On commit the backup goes, and with it the emptied directory
+	if err := w.Remove(path); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := w.Remove(sub); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if Exists(sub) {
+		t.Error("sub should be removed on commit")
+	}
+}
+
+func TestTransactional_Commit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(true, Transactional())
+	if err := w.WriteString(path, "new"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("a.txt = %q, want %q", got, "new")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Backups should be removed on commit, got %d entries", len(entries))
+	}
+
+	// Nothing is left to roll back
+	if err := w.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	got, _ = os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("a.txt = %q after committed rollback, want %q", got, "new")
+	}
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/diff"
+)
+
+// Markers delimit protected regions: user code between a begin marker
+// (followed by a region id) and an end marker survives regeneration.
+// Markers are matched anywhere in a line, so they can sit in any comment
+// syntax, e.g. "// render:keep begin imports" or "<!-- render:keep end -->".
+type Markers struct {
+	Begin string `json:"begin" yaml:"begin"`
+	End   string `json:"end" yaml:"end"`
+}
+
+// DefaultMarkers are used for files whose extension has no markers configured.
+var DefaultMarkers = Markers{Begin: "render:keep begin", End: "render:keep end"}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// Regions sets the protected region markers per file extension (e.g. ".go").
+// Other files use DefaultMarkers.
+func Regions(markers map[string]Markers) Option {
+	return func(w *Writer) {
+		w.markers = markers
+	}
+}
+
+// markersFor returns the region markers for a file path.
+func (w *Writer) markersFor(path string) Markers {
+	if m, ok := w.markers[filepath.Ext(path)]; ok {
+		return m
+	}
+	return DefaultMarkers
+}
+
+// Merge returns content with the protected regions of the existing file at
+// path carried over, which is what Write would store there. If there is no
+// regular file at path, content is returned unchanged.
+func (w *Writer) Merge(path string, content []byte) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return content, nil
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing file %s: %w", path, err)
+	}
+	return w.merge(path, existing, content)
+}
+
+// merge carries the protected regions of existing over into content.
+func (w *Writer) merge(path string, existing, content []byte) ([]byte, error) {
+	markers := w.markersFor(path)
+
+	oldLines := diff.SplitLines(string(existing))
+	oldRegions, err := findRegions(oldLines, markers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(oldRegions) == 0 {
+		return content, nil
+	}
+
+	newLines := diff.SplitLines(string(content))
+	newRegions, err := findRegions(newLines, markers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: rendered content: %w", path, err)
+	}
+
+	inTemplate := make(map[string]bool, len(newRegions))
+	for _, r := range newRegions {
+		inTemplate[r.id] = true
+	}
+	byID := make(map[string]region, len(oldRegions))
+	for _, r := range oldRegions {
+		if !inTemplate[r.id] {
+			return nil, fmt.Errorf("%s: protected region %q (line %d) is no longer in the template; move its code out or remove the region markers", path, r.id, r.begin+1)
+		}
+		byID[r.id] = r
+	}
+
+	// Copy the rendered lines, swapping in each region body from the existing file
+	var sb strings.Builder
+	next := 0
+	for _, r := range newRegions {
+		old, ok := byID[r.id]
+		if !ok {
+			continue
+		}
+		writeLines(&sb, newLines[next:r.begin+1])
+		writeLines(&sb, oldLines[old.begin+1:old.end])
+		next = r.end
+	}
+	writeLines(&sb, newLines[next:])
+
+	return []byte(sb.String()), nil
+}
+
+// region is a protected region, as line indexes of its begin and end markers.
+type region struct {
+	id         string
+	begin, end int
+}
+
+// findRegions locates the protected regions in lines.
+func findRegions(lines []string, m Markers) ([]region, error) {
+	var regions []region
+	seen := make(map[string]bool)
+	open := -1
+
+	for i, line := range lines {
+		if idx := strings.Index(line, m.Begin); idx >= 0 {
+			fields := strings.Fields(line[idx+len(m.Begin):])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: protected region has no id", i+1)
+			}
+			if open >= 0 {
+				return nil, fmt.Errorf("line %d: protected region %q starts inside region %q", i+1, fields[0], regions[open].id)
+			}
+			if seen[fields[0]] {
+				return nil, fmt.Errorf("line %d: duplicate protected region %q", i+1, fields[0])
+			}
+			seen[fields[0]] = true
+			regions = append(regions, region{id: fields[0], begin: i})
+			open = len(regions) - 1
+			continue
+		}
+		if strings.Contains(line, m.End) {
+			if open < 0 {
+				return nil, fmt.Errorf("line %d: end of protected region without a beginning", i+1)
+			}
+			regions[open].end = i
+			open = -1
+		}
+	}
+
+	if open >= 0 {
+		return nil, fmt.Errorf("line %d: protected region %q is never closed", regions[open].begin+1, regions[open].id)
+	}
+	return regions, nil
+}
+
+// writeLines appends lines to sb.
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/diff"
+	"testing"
+)
+
+func TestWrite_PreservesRegions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user.go")
+
+	existing := `package user
+
+// render:keep begin methods
+func (u *User) Greet() string { return "hi " + u.Name }
+// render:keep end
+
+type User struct{ ID int }
+`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rendered := `package user
+
+// render:keep begin methods
+// Add methods here
+// render:keep end
+
+type User struct {
+	ID   int
+	Name string
+}
+
+// render:keep begin extra
+// render:keep end
+`
+	w := New(true)
+	if err := w.WriteString(path, rendered); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := `package user
+
+// render:keep begin methods
+func (u *User) Greet() string { return "hi " + u.Name }
+// render:keep end
+
+type User struct {
+	ID   int
+	Name string
+}
+
+// render:keep begin extra
+// render:keep end
+`
+	got, _ := os.ReadFile(path)
+	if string(got) != want {
+		t.Errorf("Content =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Regenerating again is stable
+	merged, err := w.Merge(path, []byte(rendered))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if string(merged) != want {
+		t.Errorf("Merge =\n%s\nwant:\n%s", merged, want)
+	}
+}
+
+func TestWrite_RegionRemovedFromTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+
+	existing := "// render:keep begin init\nsetup()\n// render:keep end\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := New(true).WriteString(path, "package main\n")
+	if err == nil || !strings.Contains(err.Error(), `protected region "init"`) {
+		t.Fatalf("Expected missing region error, got %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != existing {
+		t.Error("File should be unchanged when a region would be lost")
+	}
+}
+
+func TestWrite_RegionMarkersByExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.sql")
+
+	existing := "-- BEGIN CUSTOM indexes\nCREATE INDEX x ON t (a);\n-- END CUSTOM\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(true, Regions(map[string]Markers{
+		".sql": {Begin: "-- BEGIN CUSTOM", End: "-- END CUSTOM"},
+	}))
+	if err := w.WriteString(path, "CREATE TABLE t (a int);\n-- BEGIN CUSTOM indexes\n-- END CUSTOM\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "CREATE TABLE t (a int);\n-- BEGIN CUSTOM indexes\nCREATE INDEX x ON t (a);\n-- END CUSTOM\n"
+	got, _ := os.ReadFile(path)
+	if string(got) != want {
+		t.Errorf("Content = %q, want %q", got, want)
+	}
+}
+
+func TestFindRegions_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no id", "// render:keep begin\n// render:keep end\n", "has no id"},
+		{"unclosed", "// render:keep begin a\n", "never closed"},
+		{"nested", "// render:keep begin a\n// render:keep begin b\n", "starts inside"},
+		{"stray end", "// render:keep end\n", "without a beginning"},
+		{"duplicate", "// render:keep begin a\n// render:keep end\n// render:keep begin a\n// render:keep end\n", "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := findRegions(diff.SplitLines(tt.content), DefaultMarkers)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("findRegions() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
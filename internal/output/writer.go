@@ -6,60 +6,37 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 )
 
 // Writer handles file output with optional overwrite protection and change detection.
+// When overwriting, protected regions of the existing file are carried over.
+// Files are replaced atomically, and in transactional mode every change can
+// be rolled back.
 type Writer struct {
-	force bool
+	force   bool
+	markers map[string]Markers // Protected region markers by file extension
+
+	transactional bool
+	journal       []change // Files written or removed since the transaction began
+	createdDirs   []string // Directories created since the transaction began
+	removedDirs   []string // Directories to remove on commit, innermost first
 }
 
 // New creates a new Writer.
-func New(force bool) *Writer {
-	return &Writer{force: force}
+func New(force bool, opts ...Option) *Writer {
+	w := &Writer{force: force}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 // Write writes content to a file.
 // If force is false and the file exists, it returns an error.
-// If the file exists and content is unchanged, it skips writing to preserve timestamps.
+// If the file exists, its protected regions are merged into content, and if
+// the result is unchanged, it skips writing to preserve timestamps.
 func (w *Writer) Write(path string, content []byte) error {
-	// Ensure parent directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
-	// Check if file exists
-	if info, err := os.Stat(path); err == nil {
-		if !info.Mode().IsRegular() {
-			return fmt.Errorf("path exists but is not a regular file: %s", path)
-		}
-
-		// File exists - check if we should overwrite
-		if !w.force {
-			return fmt.Errorf("file already exists (use --force to overwrite): %s", path)
-		}
-
-		// Check if content has changed
-		existingContent, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read existing file %s: %w", path, err)
-		}
-
-		if bytes.Equal(existingContent, content) {
-			// Content unchanged, skip writing
-			return nil
-		}
-	} else if !os.IsNotExist(err) {
-		return fmt.Errorf("failed to stat file %s: %w", path, err)
-	}
-
-	// Write the file
-	if err := os.WriteFile(path, content, 0644); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", path, err)
-	}
-
-	return nil
+	return w.WriteWithPerm(path, content, 0644)
 }
 
 // WriteString writes string content to a file.
@@ -89,12 +66,6 @@ func (w *Writer) Copy(src, dst string) error {
 
 // WriteWithPerm writes content to a file with specified permissions.
 func (w *Writer) WriteWithPerm(path string, content []byte, perm os.FileMode) error {
-	// Ensure parent directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
 	// Check if file exists
 	if info, err := os.Stat(path); err == nil {
 		if !info.Mode().IsRegular() {
@@ -112,6 +83,12 @@ func (w *Writer) WriteWithPerm(path string, content []byte, perm os.FileMode) er
 			return fmt.Errorf("failed to read existing file %s: %w", path, err)
 		}
 
+		// Keep user code in protected regions
+		content, err = w.merge(path, existingContent, content)
+		if err != nil {
+			return err
+		}
+
 		if bytes.Equal(existingContent, content) {
 			// Content unchanged, skip writing
 			return nil
@@ -121,11 +98,7 @@ func (w *Writer) WriteWithPerm(path string, content []byte, perm os.FileMode) er
 	}
 
 	// Write the file with specified permissions
-	if err := os.WriteFile(path, content, perm); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", path, err)
-	}
-
-	return nil
+	return w.writeFile(path, content, perm)
 }
 
 // CopyReader copies content from a reader to a file.
@@ -152,14 +125,8 @@ func (w *Writer) WriteIfNotExists(path string, content []byte, perm os.FileMode)
 	}
 
 	// File doesn't exist, create it
-	// Ensure parent directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return false, fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
-	if err := os.WriteFile(path, content, perm); err != nil {
-		return false, fmt.Errorf("failed to write file %s: %w", path, err)
+	if err := w.writeFile(path, content, perm); err != nil {
+		return false, err
 	}
 
 	return false, nil
@@ -190,15 +157,9 @@ func (w *Writer) CopyIfNotExists(src, dst string) (skipped bool, err error) {
 		return false, fmt.Errorf("failed to read source file %s: %w", src, err)
 	}
 
-	// Ensure parent directory exists
-	dir := filepath.Dir(dst)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return false, fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
 	// Write to destination
-	if err := os.WriteFile(dst, content, srcInfo.Mode().Perm()); err != nil {
-		return false, fmt.Errorf("failed to write file %s: %w", dst, err)
+	if err := w.writeFile(dst, content, srcInfo.Mode().Perm()); err != nil {
+		return false, err
 	}
 
 	return false, nil
@@ -0,0 +1,321 @@
+// Package prompt asks for the data values a template directory declares as
+// questions, cookiecutter style, when the data doesn't already supply them.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/data"
+	"github.com/wernerstrydom/render/internal/engine"
+)
+
+// Question is a data value to ask for.
+type Question struct {
+	Name    string `json:"name" yaml:"name"`       // Data path the answer is set at, such as "db.port"
+	Prompt  string `json:"prompt" yaml:"prompt"`   // Text to ask with, Name if empty
+	Type    string `json:"type" yaml:"type"`       // string (the default), int or bool
+	Default any    `json:"default" yaml:"default"` // Answer when none is given; nil = an answer is required
+	Choices []any  `json:"choices" yaml:"choices"` // Allowed answers, if any
+	Pattern string `json:"pattern" yaml:"pattern"` // Regular expression the answer as typed must match
+	When    string `json:"when" yaml:"when"`       // Template condition on the data, as in paths; the question is skipped if false
+
+	cond *engine.Template // When, compiled by Compile
+}
+
+// Validate checks the question's type, choices, pattern, default and
+// condition.
+func (q *Question) Validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, _, err := data.Lookup(nil, q.Name); err != nil {
+		return fmt.Errorf("name: %w", err)
+	}
+	switch q.Type {
+	case "", "string", "int", "bool":
+	default:
+		return fmt.Errorf("unknown type %q (expected string, int or bool)", q.Type)
+	}
+	if q.Pattern != "" {
+		if _, err := regexp.Compile(q.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	for i, choice := range q.Choices {
+		if _, err := q.convert(choice); err != nil {
+			return fmt.Errorf("choices[%d]: %w", i, err)
+		}
+	}
+	if q.Default != nil {
+		if _, err := q.check(q.Default); err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+	}
+	_, err := q.condition(engine.New())
+	return err
+}
+
+// Compile compiles the question's condition with eng, so it has the
+// engine's functions, partials and strictness. Ask compiles the conditions
+// of questions that weren't with a default engine.
+func (q *Question) Compile(eng *engine.Engine) error {
+	cond, err := q.condition(eng)
+	if err != nil {
+		return err
+	}
+	q.cond = cond
+	return nil
+}
+
+// condition compiles the question's condition with eng; nil if it has none.
+func (q *Question) condition(eng *engine.Engine) (*engine.Template, error) {
+	if q.When == "" {
+		return nil, nil
+	}
+	cond, err := eng.Compile(q.Name+" (when)", q.When)
+	if err != nil {
+		return nil, fmt.Errorf("invalid when template syntax: %w", err)
+	}
+	return cond, nil
+}
+
+// Answer is the value a question was answered with.
+type Answer struct {
+	Name  string
+	Value any
+}
+
+// Unanswered lists the questions left without an answer, by name.
+type Unanswered []string
+
+func (u Unanswered) Error() string {
+	return "unanswered questions: " + strings.Join(u, ", ")
+}
+
+// Options configures Ask.
+type Options struct {
+	In      io.Reader // Answers, one per line
+	Out     io.Writer // Where questions are asked
+	NoInput bool      // Don't ask; take the defaults and fail on the rest
+
+	// Defaults are the template's default data, which d is merged over
+	// when rendered. Questions they answer aren't asked, and conditions
+	// see them, but they aren't copied into the returned data.
+	Defaults map[string]any
+}
+
+// Ask asks the questions d (merged over opts.Defaults) doesn't answer, in
+// order, and returns a copy of d with the answers set, along with the
+// answers. An empty answer, or the end of the input, takes the default.
+// Questions whose condition is false for the data and the answers before
+// them are skipped. Questions left
+// without an answer are returned as Unanswered.
+func Ask(questions []Question, d any, opts Options) (any, []Answer, error) {
+	var r *bufio.Reader
+	if !opts.NoInput {
+		r = bufio.NewReader(opts.In)
+	}
+
+	// view is the data as the templates see it, for lookups and conditions
+	view := d
+	if opts.Defaults != nil {
+		view = opts.Defaults
+		if d != nil {
+			view = data.Merge(opts.Defaults, d, data.MergeOptions{})
+		}
+	}
+
+	var answers []Answer
+	var unanswered Unanswered
+	for _, q := range questions {
+		_, found, err := data.Lookup(view, q.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("question %s: %w", q.Name, err)
+		}
+		if found {
+			continue
+		}
+		ok, err := q.applies(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("question %s: %w", q.Name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		var answer any
+		if r != nil {
+			if answer, err = q.ask(r, opts.Out); err != nil {
+				if !errors.Is(err, io.EOF) {
+					return nil, nil, fmt.Errorf("failed to read answer to %s: %w", q.Name, err)
+				}
+				r = nil // Nothing more to read
+			}
+		}
+		if answer == nil && q.Default != nil {
+			answer, _ = q.check(q.Default)
+		}
+		if answer == nil {
+			unanswered = append(unanswered, q.Name)
+			continue
+		}
+
+		if d, err = data.Set(d, q.Name, answer); err != nil {
+			return nil, nil, fmt.Errorf("question %s: %w", q.Name, err)
+		}
+		if view, err = data.Set(view, q.Name, answer); err != nil {
+			return nil, nil, fmt.Errorf("question %s: %w", q.Name, err)
+		}
+		answers = append(answers, Answer{Name: q.Name, Value: answer})
+	}
+	if len(unanswered) > 0 {
+		return nil, nil, unanswered
+	}
+	return d, answers, nil
+}
+
+// applies reports whether the question's condition holds for d, the way
+// a when: condition on a path does.
+func (q *Question) applies(d any) (bool, error) {
+	if q.When == "" {
+		return true, nil
+	}
+	cond := q.cond
+	if cond == nil {
+		var err error
+		if cond, err = q.condition(engine.New()); err != nil {
+			return false, err
+		}
+	}
+	ok, err := cond.IsTrue(d)
+	if err != nil {
+		return false, fmt.Errorf("when: %w", err)
+	}
+	return ok, nil
+}
+
+// ask asks the question until it gets a valid answer. It returns nil for an
+// empty answer and io.EOF at the end of the input.
+func (q *Question) ask(r *bufio.Reader, out io.Writer) (any, error) {
+	for {
+		_, _ = fmt.Fprint(out, q.label())
+		line, err := r.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			if errors.Is(err, io.EOF) {
+				_, _ = fmt.Fprintln(out)
+			}
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if q.Default != nil {
+				return nil, nil
+			}
+			_, _ = fmt.Fprintln(out, "  an answer is required")
+			continue
+		}
+		answer, err := q.check(line)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// label formats the question with its choices and default.
+func (q *Question) label() string {
+	var sb strings.Builder
+	sb.WriteString(q.Prompt)
+	if q.Prompt == "" {
+		sb.WriteString(q.Name)
+	}
+	if len(q.Choices) > 0 {
+		choices := make([]string, len(q.Choices))
+		for i, choice := range q.Choices {
+			choices[i] = fmt.Sprint(choice)
+		}
+		fmt.Fprintf(&sb, " (%s)", strings.Join(choices, ", "))
+	}
+	if q.Default != nil {
+		fmt.Fprintf(&sb, " [%v]", q.Default)
+	}
+	sb.WriteString(": ")
+	return sb.String()
+}
+
+// check converts v to the question's type and checks it against the
+// pattern and choices.
+func (q *Question) check(v any) (any, error) {
+	answer, err := q.convert(v)
+	if err != nil {
+		return nil, err
+	}
+	if q.Pattern != "" {
+		re, err := regexp.Compile(q.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		if !re.MatchString(fmt.Sprint(v)) {
+			return nil, fmt.Errorf("%q does not match %s", fmt.Sprint(v), q.Pattern)
+		}
+	}
+	if len(q.Choices) == 0 {
+		return answer, nil
+	}
+	for _, choice := range q.Choices {
+		if c, err := q.convert(choice); err == nil && reflect.DeepEqual(c, answer) {
+			return answer, nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not one of the choices", v)
+}
+
+// convert converts v, typed or as typed in, to the question's type.
+func (q *Question) convert(v any) (any, error) {
+	switch q.Type {
+	case "int":
+		switch n := v.(type) {
+		case int:
+			return n, nil
+		case int64:
+			return int(n), nil
+		case float64:
+			if n == float64(int(n)) {
+				return int(n), nil
+			}
+		case string:
+			if i, err := strconv.Atoi(n); err == nil {
+				return i, nil
+			}
+		}
+		return nil, fmt.Errorf("%v is not an integer", v)
+	case "bool":
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			switch strings.ToLower(b) {
+			case "y", "yes", "true":
+				return true, nil
+			case "n", "no", "false":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("%v is not yes or no", v)
+	default:
+		switch v.(type) {
+		case map[string]any, []any, nil:
+			return nil, fmt.Errorf("%v is not a string", v)
+		}
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,132 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var questions = []Question{
+	{Name: "name", Prompt: "Service name", Pattern: "^[a-z]+$"},
+	{Name: "kind", Choices: []any{"http", "grpc"}, Default: "http"},
+	{Name: "http.port", Type: "int", Default: 8080, When: `{{ eq .kind "http" }}`},
+	{Name: "db", Type: "bool", Default: false},
+}
+
+func TestAsk(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader("Bad Name\napi\nsoap\n\n9090\ny\n")
+
+	d, answers, err := Ask(questions, map[string]any{}, Options{In: in, Out: &out})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	want := map[string]any{"name": "api", "kind": "http", "http": map[string]any{"port": 9090}, "db": true}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Ask() = %v, want %v", d, want)
+	}
+	if len(answers) != 4 || answers[2].Name != "http.port" || answers[2].Value != 9090 {
+		t.Errorf("answers = %v", answers)
+	}
+
+	for _, s := range []string{
+		"Service name: ",
+		`"Bad Name" does not match ^[a-z]+$`,
+		"kind (http, grpc) [http]: ",
+		"soap is not one of the choices",
+		"http.port [8080]: ",
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected %q in output:\n%s", s, out.String())
+		}
+	}
+}
+
+func TestAsk_SkipsAnsweredAndFalseConditions(t *testing.T) {
+	var out bytes.Buffer
+	d, answers, err := Ask(questions, map[string]any{"name": "api", "kind": "grpc"}, Options{In: strings.NewReader("\n"), Out: &out})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	want := map[string]any{"name": "api", "kind": "grpc", "db": false}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Ask() = %v, want %v", d, want)
+	}
+	if len(answers) != 1 || out.String() != "db [false]: " {
+		t.Errorf("answers = %v, output = %q", answers, out.String())
+	}
+}
+
+func TestAsk_Defaults(t *testing.T) {
+	var out bytes.Buffer
+	defaults := map[string]any{"kind": "grpc", "name": "api"}
+	d, answers, err := Ask(questions, map[string]any{"team": "ops"}, Options{In: strings.NewReader("true\n"), Out: &out, Defaults: defaults})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	// The defaults answer name and kind, and the condition on kind sees them,
+	// but only the answers are set in the data
+	want := map[string]any{"team": "ops", "db": true}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Ask() = %v, want %v", d, want)
+	}
+	if len(answers) != 1 || out.String() != "db [false]: " {
+		t.Errorf("answers = %v, output = %q", answers, out.String())
+	}
+	if len(defaults) != 2 {
+		t.Errorf("defaults were modified: %v", defaults)
+	}
+}
+
+func TestAsk_NoInput(t *testing.T) {
+	_, _, err := Ask(questions, map[string]any{}, Options{NoInput: true})
+	var unanswered Unanswered
+	if !errors.As(err, &unanswered) || !reflect.DeepEqual(unanswered, Unanswered{"name"}) {
+		t.Fatalf("Ask() error = %v, want name unanswered", err)
+	}
+
+	d, _, err := Ask(questions, map[string]any{"name": "api"}, Options{NoInput: true})
+	if err != nil {
+		t.Fatalf("Ask() error = %v", err)
+	}
+	want := map[string]any{"name": "api", "kind": "http", "http": map[string]any{"port": 8080}, "db": false}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Ask() = %v, want %v", d, want)
+	}
+}
+
+func TestAsk_EndOfInput(t *testing.T) {
+	// The end of the input takes the defaults
+	var out bytes.Buffer
+	_, _, err := Ask(questions, map[string]any{}, Options{In: strings.NewReader(""), Out: &out})
+	if err == nil || err.Error() != "unanswered questions: name" {
+		t.Errorf("Ask() error = %v, want name unanswered", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := map[string]Question{
+		"no name":          {},
+		"bad name":         {Name: "a..b"},
+		"unknown type":     {Name: "a", Type: "float"},
+		"bad pattern":      {Name: "a", Pattern: "("},
+		"bad choice":       {Name: "a", Type: "int", Choices: []any{"x"}},
+		"default choice":   {Name: "a", Choices: []any{"x"}, Default: "y"},
+		"default type":     {Name: "a", Type: "bool", Default: "maybe"},
+		"default pattern":  {Name: "a", Pattern: "^[0-9]+$", Default: "x"},
+		"bad when":         {Name: "a", When: "{{ .a"},
+		"default not text": {Name: "a", Default: []any{"x"}},
+	}
+	for name, q := range invalid {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%s: Validate() succeeded, want error", name)
+		}
+	}
+	for _, q := range questions {
+		if err := q.Validate(); err != nil {
+			t.Errorf("Validate(%s) error = %v", q.Name, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package provenance records the inputs an output directory was rendered
+// from, so the render can be understood and reproduced later.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wernerstrydom/render/internal/manifest"
+	"github.com/wernerstrydom/render/internal/output"
+)
+
+// FileName is the name of the provenance file in the output directory.
+const FileName = ".render-provenance.json"
+
+// version is the provenance format version.
+const version = 1
+
+// Record describes one render. Paths are relative to the output directory
+// holding the record, unless they could not be made relative.
+type Record struct {
+	Version       int               `json:"version"`
+	RenderVersion string            `json:"renderVersion"`
+	Template      string            `json:"template"`
+	Data          []string          `json:"data"`   // Data sources in merge order
+	Output        string            `json:"output"` // --output, relative to the output directory
+	ListMerge     string            `json:"listMerge,omitempty"`
+	MergeKey      string            `json:"mergeKey,omitempty"`
+	CSV           *CSV              `json:"csv,omitempty"`
+	DirLayout     string            `json:"dirLayout,omitempty"`
+	EnvPrefix     string            `json:"envPrefix,omitempty"`
+	EnvRoot       bool              `json:"envRoot,omitempty"`
+	Set           []Setting         `json:"set,omitempty"` // --set style flags in command-line order
+	Query         string            `json:"query,omitempty"`
+	ItemQuery     string            `json:"itemQuery,omitempty"`
+	Stream        bool              `json:"stream,omitempty"`
+	Control       string            `json:"control,omitempty"`
+	Partials      string            `json:"partials,omitempty"`
+	Schema        string            `json:"schema,omitempty"`
+	Strict        bool              `json:"strict,omitempty"`
+	SkipEmpty     bool              `json:"skipEmpty,omitempty"`
+	AllowEnv      bool              `json:"allowEnv,omitempty"`
+	Inputs        map[string]string `json:"inputs"` // Input file → content hash
+}
+
+// CSV holds the --csv flags.
+type CSV struct {
+	Delimiter string            `json:"delimiter,omitempty"`
+	NoHeader  bool              `json:"noHeader,omitempty"`
+	Columns   []string          `json:"columns,omitempty"`
+	Infer     bool              `json:"infer,omitempty"`
+	Types     map[string]string `json:"types,omitempty"`
+}
+
+// Setting is one --set style flag.
+type Setting struct {
+	Flag  string `json:"flag"`  // set, set-string, set-json or set-file
+	Value string `json:"value"` // key=value; the path of set-file is recorded like other paths
+}
+
+// File returns the file of a set-file setting, or "" for other settings.
+func (s Setting) File() string {
+	if s.Flag != "set-file" {
+		return ""
+	}
+	_, file, _ := strings.Cut(s.Value, "=")
+	return file
+}
+
+// New creates a record with the current format version.
+func New() *Record {
+	return &Record{Version: version, Inputs: make(map[string]string)}
+}
+
+// Load reads the record in dir.
+func Load(dir string) (*Record, error) {
+	path := filepath.Join(dir, FileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance: %w", err)
+	}
+
+	r := New()
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("failed to parse provenance %s: %w", path, err)
+	}
+	if r.Version != version {
+		return nil, fmt.Errorf("provenance %s has unsupported version %d", path, r.Version)
+	}
+	if r.Template == "" || (len(r.Data) == 0 && r.EnvPrefix == "") || r.Output == "" {
+		return nil, fmt.Errorf("provenance %s must record template, data and output", path)
+	}
+	if r.Inputs == nil {
+		r.Inputs = make(map[string]string)
+	}
+	return r, nil
+}
+
+// Save writes the record to dir, creating dir if needed. The record is
+// written to a temporary file and renamed into place, so a failed save
+// leaves the previous record intact.
+func (r *Record) Save(dir string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode provenance: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := output.WriteFileAtomic(filepath.Join(dir, FileName), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	return nil
+}
+
+// Rel returns path relative to dir, falling back to the absolute path.
+func Rel(dir, path string) (string, error) {
+	dirAbs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	pathAbs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(dirAbs, pathAbs); err == nil {
+		return filepath.ToSlash(rel), nil
+	}
+	return pathAbs, nil
+}
+
+// Resolve returns a path recorded relative to dir.
+func Resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
+
+// Hash records the content hash of every regular file under the recorded
+// input paths, which may name files or directories.
+func (r *Record) Hash(dir string) error {
+	inputs, err := hashInputs(dir, r.inputPaths())
+	if err != nil {
+		return err
+	}
+	r.Inputs = inputs
+	return nil
+}
+
+// Changed returns the inputs that were added, removed or modified since the
+// record was made, sorted.
+func (r *Record) Changed(dir string) ([]string, error) {
+	now, err := hashInputs(dir, r.inputPaths())
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for path, hash := range r.Inputs {
+		if now[path] != hash {
+			changed = append(changed, path)
+		}
+	}
+	for path := range now {
+		if _, ok := r.Inputs[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// inputPaths returns the recorded paths of every input.
+func (r *Record) inputPaths() []string {
+	paths := append([]string{r.Template}, r.Data...)
+	for _, s := range r.Set {
+		if file := s.File(); file != "" {
+			paths = append(paths, file)
+		}
+	}
+	for _, p := range []string{r.Control, r.Partials, r.Schema} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// hashInputs hashes the files under each path, keyed by the file's path in
+// the same form as the recorded path.
+func hashInputs(dir string, paths []string) (map[string]string, error) {
+	inputs := make(map[string]string)
+	for _, p := range paths {
+		root := Resolve(dir, p)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			hash, err := hashFile(path)
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			key := p
+			if relPath != "." {
+				key = p + "/" + filepath.ToSlash(relPath)
+			}
+			inputs[key] = hash
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			// A missing input shows up as removed
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", p, err)
+		}
+	}
+	return inputs, nil
+}
+
+// hashFile returns the hash of the file at path, as recorded in manifests,
+// reading it a block at a time so large data sources aren't held in memory.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return manifest.FormatHash(sum), nil
+}
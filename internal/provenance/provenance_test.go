@@ -0,0 +1,95 @@
+package provenance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wernerstrydom/render/internal/manifest"
+)
+
+func TestRecord_SaveLoadChanged(t *testing.T) {
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "out")
+	tmplDir := filepath.Join(dir, "templates")
+	dataPath := filepath.Join(dir, "data.json")
+
+	if err := os.MkdirAll(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmplDir, "a.tmpl"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dataPath, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := New()
+	var err error
+	if rec.Template, err = Rel(outDir, tmplDir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Rel(outDir, dataPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Data = []string{data}
+	rec.Output = "."
+	rec.Set = []Setting{{Flag: "set", Value: "replicas=3"}}
+	if rec.Template != "../templates" || data != "../data.json" {
+		t.Errorf("Rel() = %q, %q; want paths relative to the output directory", rec.Template, data)
+	}
+
+	if err := rec.Hash(outDir); err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if got, want := rec.Inputs["../data.json"], manifest.Hash([]byte("{}")); got != want {
+		t.Errorf("Inputs[../data.json] = %q, want %q", got, want)
+	}
+	if err := rec.Save(outDir); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(outDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, rec) {
+		t.Errorf("Load() = %+v, want %+v", loaded, rec)
+	}
+
+	changed, err := loaded.Changed(outDir)
+	if err != nil || len(changed) != 0 {
+		t.Fatalf("Changed() = %v, %v; want nothing", changed, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmplDir, "a.tmpl"), []byte("A"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmplDir, "b.tmpl"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(dataPath); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err = loaded.Changed(outDir)
+	if err != nil {
+		t.Fatalf("Changed failed: %v", err)
+	}
+	want := []string{"../data.json", "../templates/a.tmpl", "../templates/b.tmpl"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("Changed() = %v, want %v", changed, want)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected error for a record without inputs")
+	}
+}
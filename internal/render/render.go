@@ -25,6 +25,21 @@ type Output struct {
 // Plan represents the complete rendering operation.
 type Plan struct {
 	Outputs []Output
+	Skipped []Skip // Template directory entries left out of Outputs
+}
+
+// Reasons for skipping a template directory entry.
+const (
+	SkipCondition = "condition" // Excluded by a when: condition
+	SkipEmpty     = "empty"     // Rendered empty or whitespace-only
+)
+
+// Skip is a template directory entry left out of the plan. A skipped
+// directory stands for everything in it.
+type Skip struct {
+	SourcePath string // Relative path in template dir
+	OutputPath string // Absolute output path; without path mappings for SkipCondition
+	Reason     string // Why it was skipped: SkipCondition or SkipEmpty
 }
 
 // CollectConfig configures the Collect function.
@@ -34,10 +49,48 @@ type CollectConfig struct {
 	Data        any
 	Config      *config.ParsedConfig // nil = no path transformation
 	Engine      *engine.Engine
+	KeepGoing   bool // Collect every failing file instead of stopping at the first
+	SkipEmpty   bool // Leave out templates that render empty or whitespace-only, unless skipEmpty: says otherwise
+}
+
+// SourceError is a failure attributed to one template source path.
+type SourceError struct {
+	SourcePath string // Relative path in template dir
+	Err        error
+}
+
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of failures, each a *SourceError, gathered in keep-going mode.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual failures.
+func (e Errors) Unwrap() []error {
+	return e
 }
 
 // Collect walks the template directory and builds a Plan.
 // It collects all outputs into memory for validation before any writes.
+// Per-file failures are returned as *SourceError. With KeepGoing, Collect
+// renders every file and returns the partial plan together with Errors.
+// Templates are compiled through the engine's cache, so calling Collect
+// repeatedly with the same Engine (e.g. once per item) parses each file once.
+// The data is merged over the defaults declared by cfg.Config, if any.
 func Collect(cfg CollectConfig) (*Plan, error) {
 	// Resolve template directory to absolute path
 	tmplDirAbs, err := filepath.Abs(cfg.TemplateDir)
@@ -60,33 +113,27 @@ func Collect(cfg CollectConfig) (*Plan, error) {
 		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
 	}
 
+	// Parse partials into the engine's shared template set, once per engine
+	if err := cfg.Engine.LoadPartialsOnce(tmplDirAbs, func() error {
+		return loadPartials(cfg.Engine, tmplDirAbs)
+	}); err != nil {
+		return nil, err
+	}
+
+	// Merge the data over the template directory's defaults
+	cfg.Data = cfg.Config.ApplyDefaults(cfg.Data)
+
 	// Create path mapper if config exists
 	mapper := config.NewPathMapper(cfg.Config)
 
 	plan := &Plan{
 		Outputs: make([]Output, 0),
 	}
+	var failures Errors
 
-	err = filepath.Walk(tmplDirAbs, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Get relative path from template directory
-		relPath, err := filepath.Rel(tmplDirAbs, path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path: %w", err)
-		}
-
-		// Skip the root directory
-		if relPath == "." {
-			return nil
-		}
-
-		// Skip config files
-		if config.ShouldSkipConfigFile(relPath) {
-			return nil
-		}
+	// collectEntry adds the output for one template directory entry
+	collectEntry := func(path, relPath string, info os.FileInfo) error {
+		var err error
 
 		// Security: Ensure relative path doesn't escape
 		if strings.Contains(relPath, "..") {
@@ -126,15 +173,21 @@ func Collect(cfg CollectConfig) (*Plan, error) {
 			// Strip .tmpl extension for output
 			outPath = strings.TrimSuffix(outPath, ".tmpl")
 
-			// Read and render template
-			tmplContent, err := os.ReadFile(path)
+			// Compile (cached by source path) and render template
+			tmpl, err := cfg.Engine.CompileFile(filepath.ToSlash(relPath), path)
 			if err != nil {
-				return fmt.Errorf("failed to read template %s: %w", relPath, err)
+				return fmt.Errorf("failed to parse template: %w", err)
 			}
 
-			result, err := cfg.Engine.RenderString(string(tmplContent), cfg.Data)
+			result, err := tmpl.Execute(cfg.Data)
 			if err != nil {
-				return fmt.Errorf("failed to render template %s: %w", relPath, err)
+				return fmt.Errorf("failed to render template: %w", err)
+			}
+
+			// Blank output isn't written when skipping empty files
+			if strings.TrimSpace(result) == "" && mapper.SkipEmpty(relPath, cfg.SkipEmpty) {
+				plan.Skipped = append(plan.Skipped, Skip{SourcePath: relPath, OutputPath: outPath, Reason: SkipEmpty})
+				return nil
 			}
 
 			plan.Outputs = append(plan.Outputs, Output{
@@ -160,6 +213,63 @@ func Collect(cfg CollectConfig) (*Plan, error) {
 			})
 		}
 
+		return nil
+	}
+
+	err = filepath.Walk(tmplDirAbs, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Get relative path from template directory
+		relPath, err := filepath.Rel(tmplDirAbs, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		// Skip the root directory
+		if relPath == "." {
+			return nil
+		}
+
+		// Skip config files and the schema and defaults they name
+		if config.ShouldSkipConfigFile(relPath) || relPath == cfg.Config.Schema() || relPath == cfg.Config.DefaultsFile() {
+			return nil
+		}
+
+		// Skip partials - they are parsed into the shared template set, never emitted
+		if !info.IsDir() && IsPartial(relPath) {
+			return nil
+		}
+
+		// Leave out entries whose when: condition is false, with everything in them
+		include, err := mapper.Include(relPath, cfg.Data)
+		if err == nil && !include {
+			plan.Skipped = append(plan.Skipped, Skip{
+				SourcePath: relPath,
+				OutputPath: skippedPath(outDirAbs, relPath, info.IsDir()),
+				Reason:     SkipCondition,
+			})
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to evaluate condition for %s: %w", relPath, err)
+		} else {
+			err = collectEntry(path, relPath, info)
+		}
+
+		// Record failures against their source; in keep-going mode carry on
+		if err != nil {
+			srcErr := &SourceError{SourcePath: filepath.ToSlash(relPath), Err: err}
+			if !cfg.KeepGoing {
+				return srcErr
+			}
+			failures = append(failures, srcErr)
+		}
+
 		return nil
 	})
 
@@ -167,9 +277,64 @@ func Collect(cfg CollectConfig) (*Plan, error) {
 		return nil, err
 	}
 
+	if len(failures) > 0 {
+		return plan, failures
+	}
+
 	return plan, nil
 }
 
+// skippedPath returns the absolute path a skipped entry stands for. Path
+// mappings aren't applied: the data they need is often what a false
+// condition is missing.
+func skippedPath(outDirAbs, relPath string, isDir bool) string {
+	outPath := filepath.Join(outDirAbs, relPath)
+	if !isDir {
+		outPath = strings.TrimSuffix(outPath, ".tmpl")
+	}
+	return outPath
+}
+
+// PartialsDir is the template subdirectory whose .tmpl files are all partials.
+const PartialsDir = "_partials"
+
+// IsPartial reports whether a template-relative path is a partial: a .tmpl
+// file inside the top-level _partials directory. Other files there are
+// copied like any other, and .tmpl files elsewhere are ordinary templates,
+// whatever their name.
+func IsPartial(relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	return strings.HasPrefix(relPath, PartialsDir+"/") && strings.HasSuffix(relPath, ".tmpl")
+}
+
+// loadPartials parses every partial in the template directory into the
+// engine's shared template set, named by its slash-separated relative path.
+func loadPartials(eng *engine.Engine, tmplDirAbs string) error {
+	return filepath.Walk(tmplDirAbs, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(tmplDirAbs, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		if !IsPartial(relPath) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read partial %s: %w", relPath, err)
+		}
+
+		return eng.AddPartial(filepath.ToSlash(relPath), string(content))
+	})
+}
+
 // Validate checks the Plan for collisions and security issues.
 // Returns all errors found (doesn't stop at first error).
 func (p *Plan) Validate() []error {
@@ -179,9 +344,12 @@ func (p *Plan) Validate() []error {
 	for _, out := range p.Outputs {
 		// Check for collisions
 		if existing, ok := seen[out.OutputPath]; ok {
-			errs = append(errs, fmt.Errorf(
-				"output path collision: %q produced by both:\n  - %s\n  - %s",
-				out.OutputPath, existing, out.SourcePath))
+			errs = append(errs, &SourceError{
+				SourcePath: filepath.ToSlash(out.SourcePath),
+				Err: fmt.Errorf(
+					"output path collision: %q produced by both:\n  - %s\n  - %s",
+					out.OutputPath, existing, out.SourcePath),
+			})
 		}
 		seen[out.OutputPath] = out.SourcePath
 	}
@@ -207,58 +375,48 @@ func (p *Plan) Preview() string {
 // ExecuteResult contains information about executed outputs.
 type ExecuteResult struct {
 	Skipped map[string]bool // Paths that were skipped due to no-overwrite
+	Done    int             // Outputs written or skipped before Execute returned
 }
 
-// Execute writes all files in the Plan.
-// Returns ExecuteResult with information about skipped files.
+// Execute writes all files in the Plan. The writer creates parent
+// directories and replaces each file atomically; it stops at the first
+// failure, leaving earlier files written unless the writer is transactional.
+// Returns ExecuteResult with information about skipped files; on failure,
+// its Done counts the outputs handled before the failing one.
 func (p *Plan) Execute(writer *output.Writer) (*ExecuteResult, error) {
 	result := &ExecuteResult{
 		Skipped: make(map[string]bool),
 	}
 
 	for _, out := range p.Outputs {
-		// Ensure parent directory exists
-		dir := filepath.Dir(out.OutputPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		skipped, err := writeOutput(writer, out)
+		if err != nil {
+			return result, err
 		}
-
-		if out.CopyFrom != "" {
-			// Copy file
-			if !out.Overwrite {
-				skipped, err := writer.CopyIfNotExists(out.CopyFrom, out.OutputPath)
-				if err != nil {
-					return nil, err
-				}
-				if skipped {
-					result.Skipped[out.OutputPath] = true
-				}
-			} else {
-				if err := writer.Copy(out.CopyFrom, out.OutputPath); err != nil {
-					return nil, err
-				}
-			}
-		} else {
-			// Write rendered content
-			if !out.Overwrite {
-				skipped, err := writer.WriteIfNotExists(out.OutputPath, out.Content, out.Permissions)
-				if err != nil {
-					return nil, err
-				}
-				if skipped {
-					result.Skipped[out.OutputPath] = true
-				}
-			} else {
-				if err := writer.WriteWithPerm(out.OutputPath, out.Content, out.Permissions); err != nil {
-					return nil, err
-				}
-			}
+		if skipped {
+			result.Skipped[out.OutputPath] = true
 		}
+		result.Done++
 	}
 
 	return result, nil
 }
 
+// writeOutput writes or copies one output, reporting whether it was skipped
+// because it must not be overwritten.
+func writeOutput(writer *output.Writer, out Output) (bool, error) {
+	switch {
+	case out.CopyFrom != "" && !out.Overwrite:
+		return writer.CopyIfNotExists(out.CopyFrom, out.OutputPath)
+	case out.CopyFrom != "":
+		return false, writer.Copy(out.CopyFrom, out.OutputPath)
+	case !out.Overwrite:
+		return writer.WriteIfNotExists(out.OutputPath, out.Content, out.Permissions)
+	default:
+		return false, writer.WriteWithPerm(out.OutputPath, out.Content, out.Permissions)
+	}
+}
+
 // OutputCount returns the number of outputs in the plan.
 func (p *Plan) OutputCount() int {
 	return len(p.Outputs)
@@ -1,8 +1,10 @@
 package render
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -139,6 +141,127 @@ func TestCollect_SkipsConfigFile(t *testing.T) {
 	}
 }
 
+func TestCollect_Defaults(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "app.conf.tmpl", "{{ .name }}:{{ .port }}")
+	writeFile(t, tmplDir, "defaults.yaml", "name: app\nport: 8080\n")
+	writeFile(t, tmplDir, ".render.yaml", "defaults: defaults.yaml\n")
+
+	cfg, err := config.Load(tmplDir)
+	if err != nil {
+		t.Fatalf("config.Load failed: %v", err)
+	}
+
+	plan, err := Collect(CollectConfig{
+		TemplateDir: tmplDir,
+		OutputDir:   filepath.Join(dir, "output"),
+		Data:        map[string]any{"port": 9090},
+		Config:      cfg,
+		Engine:      engine.New(),
+	})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	// The defaults file the config names is not copied, and the data wins over it
+	if len(plan.Outputs) != 1 {
+		t.Fatalf("Expected 1 output, got %d", len(plan.Outputs))
+	}
+	if got := string(plan.Outputs[0].Content); got != "app:9090" {
+		t.Errorf("Content = %q, want %q", got, "app:9090")
+	}
+}
+
+func TestCollect_Conditions(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "README.md", "readme")
+	writeFile(t, tmplDir, "Dockerfile.tmpl", "FROM {{ .docker.image }}")
+	writeFile(t, tmplDir, "deploy/app.yaml", "kind: Deployment")
+	writeFile(t, tmplDir, ".render.yaml", `paths:
+  "Dockerfile.tmpl":
+    when: "{{ .docker }}"
+  "deploy":
+    path: "{{ .docker.image }}"
+    when: "{{ .docker }}"
+`)
+
+	cfg, err := config.Load(tmplDir)
+	if err != nil {
+		t.Fatalf("config.Load failed: %v", err)
+	}
+
+	outDir := filepath.Join(dir, "output")
+	plan, err := Collect(CollectConfig{
+		TemplateDir: tmplDir,
+		OutputDir:   outDir,
+		Data:        map[string]any{},
+		Config:      cfg,
+		Engine:      engine.New(engine.Strict(true)),
+	})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	if len(plan.Outputs) != 1 || plan.Outputs[0].SourcePath != "README.md" {
+		t.Fatalf("Expected only README.md, got %+v", plan.Outputs)
+	}
+
+	// Skipped paths are reported without their path mappings
+	want := []Skip{
+		{SourcePath: "Dockerfile.tmpl", OutputPath: filepath.Join(outDir, "Dockerfile"), Reason: SkipCondition},
+		{SourcePath: "deploy", OutputPath: filepath.Join(outDir, "deploy"), Reason: SkipCondition},
+	}
+	if !slices.Equal(plan.Skipped, want) {
+		t.Errorf("Skipped = %+v, want %+v", plan.Skipped, want)
+	}
+}
+
+func TestCollect_SkipEmpty(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "blank.txt.tmpl", "{{ if .x }}x{{ end }}\n  \n")
+	writeFile(t, tmplDir, "full.txt.tmpl", "content")
+	writeFile(t, tmplDir, ".gitkeep", "")
+
+	outDir := filepath.Join(dir, "output")
+	collect := func(skip bool) *Plan {
+		t.Helper()
+		plan, err := Collect(CollectConfig{
+			TemplateDir: tmplDir,
+			OutputDir:   outDir,
+			Data:        map[string]any{},
+			Engine:      engine.New(),
+			SkipEmpty:   skip,
+		})
+		if err != nil {
+			t.Fatalf("Collect failed: %v", err)
+		}
+		return plan
+	}
+
+	if plan := collect(false); len(plan.Outputs) != 3 || len(plan.Skipped) != 0 {
+		t.Errorf("Without SkipEmpty expected 3 outputs, got %+v", plan)
+	}
+
+	// Copied files are kept even when empty
+	plan := collect(true)
+	if len(plan.Outputs) != 2 {
+		t.Errorf("Expected 2 outputs, got %+v", plan.Outputs)
+	}
+	want := []Skip{{SourcePath: "blank.txt.tmpl", OutputPath: filepath.Join(outDir, "blank.txt"), Reason: SkipEmpty}}
+	if !slices.Equal(plan.Skipped, want) {
+		t.Errorf("Skipped = %+v, want %+v", plan.Skipped, want)
+	}
+}
+
 func TestPlan_Validate_NoCollisions(t *testing.T) {
 	plan := &Plan{
 		Outputs: []Output{
@@ -387,6 +510,35 @@ func TestPlan_Execute_NoOverwrite(t *testing.T) {
 	}
 }
 
+func TestPlan_Execute_Failure(t *testing.T) {
+	dir := t.TempDir()
+	outDir := filepath.Join(dir, "output")
+	mkdir(t, outDir)
+	// A file where a directory is needed makes the second write fail
+	if err := os.WriteFile(filepath.Join(outDir, "z"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &Plan{
+		Outputs: []Output{
+			{OutputPath: filepath.Join(outDir, "a.txt"), Content: []byte("a"), Permissions: 0644, Overwrite: true},
+			{OutputPath: filepath.Join(outDir, "z", "b.txt"), Content: []byte("b"), Permissions: 0644, Overwrite: true},
+			{OutputPath: filepath.Join(outDir, "c.txt"), Content: []byte("c"), Permissions: 0644, Overwrite: true},
+		},
+	}
+
+	result, err := plan.Execute(output.New(true))
+	if err == nil {
+		t.Fatal("Expected Execute to fail")
+	}
+	if result == nil || result.Done != 1 {
+		t.Errorf("Execute() result = %+v, want Done = 1", result)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "c.txt")); !os.IsNotExist(err) {
+		t.Error("c.txt should not be written after the failure")
+	}
+}
+
 func TestPlan_Execute_NoOverwrite_NewFile(t *testing.T) {
 	dir := t.TempDir()
 	outDir := filepath.Join(dir, "output")
@@ -428,6 +580,161 @@ func TestPlan_Execute_NoOverwrite_NewFile(t *testing.T) {
 	}
 }
 
+func TestCollect_Partials(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "_partials/k8s/labels.tmpl", `{{ define "labels" }}app: {{ .name }}{{ end }}`)
+	writeFile(t, tmplDir, "_partials/meta.tmpl", `{{ define "meta" }}team: {{ .team }}{{ end }}`)
+	writeFile(t, tmplDir, "_partials/notes.txt", "copied")
+	writeFile(t, tmplDir, "_helpers.tmpl", "{{ .team }}")
+	writeFile(t, tmplDir, "deploy/service.yaml.tmpl", "{{ template \"labels\" . }}\n{{ template \"meta\" . }}")
+	writeFile(t, tmplDir, "app/__init__.py.tmpl", "# {{ .name }}")
+
+	plan, err := Collect(CollectConfig{
+		TemplateDir: tmplDir,
+		OutputDir:   filepath.Join(dir, "output"),
+		Data:        map[string]any{"name": "api", "team": "core"},
+		Engine:      engine.New(),
+	})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	if len(plan.Outputs) != 4 {
+		for _, out := range plan.Outputs {
+			t.Logf("output: %s", out.SourcePath)
+		}
+		t.Fatalf("Expected 4 outputs (partials excluded), got %d", len(plan.Outputs))
+	}
+
+	for _, out := range plan.Outputs {
+		switch filepath.ToSlash(out.SourcePath) {
+		case "deploy/service.yaml.tmpl":
+			if string(out.Content) != "app: api\nteam: core" {
+				t.Errorf("Content = %q", string(out.Content))
+			}
+		case "app/__init__.py.tmpl":
+			if string(out.Content) != "# api" {
+				t.Errorf("Content = %q", string(out.Content))
+			}
+		case "_helpers.tmpl":
+			if string(out.Content) != "core" {
+				t.Errorf("Content = %q", string(out.Content))
+			}
+		case "_partials/notes.txt":
+			if out.CopyFrom == "" {
+				t.Errorf("%s should be copied", out.SourcePath)
+			}
+		default:
+			t.Errorf("Unexpected output %s", out.SourcePath)
+		}
+	}
+}
+
+func TestIsPartial(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"_partials/labels.tmpl", true},
+		{"_partials/nested/labels.tmpl", true},
+		{"_partials/README.md", false},
+		{"_partials", false},
+		{"_helpers.tmpl", false},
+		{"sub/_labels.tmpl", false},
+		{"__init__.py.tmpl", false},
+		{"helpers.tmpl", false},
+		{"sub/_partials/labels.tmpl", false},
+	}
+	for _, tt := range tests {
+		if got := IsPartial(tt.path); got != tt.want {
+			t.Errorf("IsPartial(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCollect_ReusesCompiledTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "item.txt.tmpl", "v1 {{ .name }}")
+
+	eng := engine.New()
+	collect := func(name string) string {
+		t.Helper()
+		plan, err := Collect(CollectConfig{
+			TemplateDir: tmplDir,
+			OutputDir:   filepath.Join(dir, name),
+			Data:        map[string]any{"name": name},
+			Engine:      eng,
+		})
+		if err != nil {
+			t.Fatalf("Collect failed: %v", err)
+		}
+		return string(plan.Outputs[0].Content)
+	}
+
+	if got := collect("a"); got != "v1 a" {
+		t.Errorf("Content = %q, want %q", got, "v1 a")
+	}
+
+	// The template was compiled on the first call; edits are not re-read
+	writeFile(t, tmplDir, "item.txt.tmpl", "v2 {{ .name }}")
+	if got := collect("b"); got != "v1 b" {
+		t.Errorf("Content = %q, want %q (cached template)", got, "v1 b")
+	}
+}
+
+func TestCollect_KeepGoing(t *testing.T) {
+	dir := t.TempDir()
+
+	tmplDir := filepath.Join(dir, "templates")
+	mkdir(t, tmplDir)
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .missing.key }}")
+	writeFile(t, tmplDir, "b.txt.tmpl", "ok")
+	writeFile(t, tmplDir, "c/d.txt.tmpl", "{{ .other.key }}")
+
+	cfg := CollectConfig{
+		TemplateDir: tmplDir,
+		OutputDir:   filepath.Join(dir, "output"),
+		Data:        map[string]any{},
+		Engine:      engine.New(engine.Strict(true)),
+	}
+
+	// Without KeepGoing the first failure stops the walk
+	plan, err := Collect(cfg)
+	if plan != nil {
+		t.Error("Expected no plan on failure")
+	}
+	var srcErr *SourceError
+	if !errors.As(err, &srcErr) || srcErr.SourcePath != "a.txt.tmpl" {
+		t.Fatalf("Expected SourceError for a.txt.tmpl, got %v", err)
+	}
+
+	cfg.KeepGoing = true
+	plan, err = Collect(cfg)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected Errors, got %v", err)
+	}
+	var sources []string
+	for _, e := range errs {
+		if !errors.As(e, &srcErr) {
+			t.Fatalf("Expected SourceError, got %v", e)
+		}
+		sources = append(sources, srcErr.SourcePath)
+	}
+	if want := []string{"a.txt.tmpl", "c/d.txt.tmpl"}; !slices.Equal(sources, want) {
+		t.Errorf("Failure sources = %v, want %v", sources, want)
+	}
+	if plan == nil || len(plan.Outputs) != 1 {
+		t.Fatalf("Expected partial plan with 1 output, got %+v", plan)
+	}
+}
+
 // Helper functions
 
 func mkdir(t *testing.T, path string) {
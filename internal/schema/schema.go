@@ -0,0 +1,122 @@
+// Package schema validates data against a JSON Schema before rendering, so
+// bad data is reported where it is rather than where a template trips on it.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/wernerstrydom/render/internal/data"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// printer formats violation messages.
+var printer = message.NewPrinter(language.English)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	path   string
+	schema *jsonschema.Schema
+}
+
+// Load reads and compiles the schema at path, which may be JSON or YAML.
+// References to other schema files are resolved relative to it.
+func Load(path string) (*Schema, error) {
+	doc, err := data.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", path, err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve schema %s: %w", path, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(abs, doc); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	s, err := c.Compile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return &Schema{path: path, schema: s}, nil
+}
+
+// Path returns the path the schema was loaded from.
+func (s *Schema) Path() string {
+	return s.path
+}
+
+// Violation is one way the data doesn't match the schema.
+type Violation struct {
+	Pointer string // JSON pointer to the offending value, "" for the root
+	Message string
+}
+
+// Error formats the violation with its pointer.
+func (v Violation) Error() string {
+	return fmt.Sprintf("at %q: %s", v.Pointer, v.Message)
+}
+
+// Violations lists every violation found, sorted by pointer.
+type Violations []Violation
+
+// Error lists the violations, one per line.
+func (v Violations) Error() string {
+	lines := make([]string, len(v))
+	for i, violation := range v {
+		lines[i] = violation.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks d against the schema. It returns Violations if d doesn't
+// match.
+func (s *Schema) Validate(d any) error {
+	err := s.schema.Validate(d)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return err
+	}
+
+	var violations Violations
+	collect(verr, &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Pointer < violations[j].Pointer
+	})
+	return violations
+}
+
+// collect adds the leaves of a validation error tree, which name the actual
+// violations; the inner nodes only say which keyword they failed under.
+func collect(e *jsonschema.ValidationError, violations *Violations) {
+	if len(e.Causes) == 0 {
+		*violations = append(*violations, Violation{
+			Pointer: pointer(e.InstanceLocation),
+			Message: e.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+	for _, cause := range e.Causes {
+		collect(cause, violations)
+	}
+}
+
+// pointer formats a location as a JSON pointer (RFC 6901).
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		tok = strings.ReplaceAll(tok, "~", "~0")
+		sb.WriteString(strings.ReplaceAll(tok, "/", "~1"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeSchema writes a schema file to a new temporary directory.
+func writeSchema(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	return path
+}
+
+const serviceSchema = `{
+  "type": "object",
+  "required": ["name", "port"],
+  "properties": {
+    "name": {"type": "string"},
+    "port": {"type": "integer", "minimum": 1},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "a/b": {"type": "string"}
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Load(writeSchema(t, "render.schema.json", serviceSchema))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := s.Validate(map[string]any{"name": "api", "port": 8080}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	// Ints from YAML and float64 from JSON are both numbers
+	if err := s.Validate(map[string]any{"name": "api", "port": float64(80)}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	err = s.Validate(map[string]any{
+		"port": 0,
+		"tags": []any{"a", 2},
+		"a/b":  1,
+	})
+	var violations Violations
+	if !errors.As(err, &violations) {
+		t.Fatalf("Validate() error = %v, want Violations", err)
+	}
+
+	var pointers []string
+	for _, v := range violations {
+		pointers = append(pointers, v.Pointer)
+	}
+	want := []string{"", "/a~1b", "/port", "/tags/1"}
+	if !reflect.DeepEqual(pointers, want) {
+		t.Errorf("pointers = %q, want %q\n%v", pointers, want, err)
+	}
+	if !strings.Contains(violations[0].Error(), `at "": missing property 'name'`) {
+		t.Errorf("violation = %q", violations[0].Error())
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	s, err := Load(writeSchema(t, "schema.yaml", "type: object\nrequired: [name]\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := s.Validate(map[string]any{}); err == nil {
+		t.Error("Validate() should fail without name")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(writeSchema(t, "bad.json", `{"type": 1}`)); err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Errorf("Load() error = %v, want invalid schema", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load() should fail for a missing file")
+	}
+}
@@ -0,0 +1,271 @@
+// Package snapshot keeps a copy of the templates and data an output
+// directory was rendered from, so a later update can render the previous
+// version again and merge template changes into locally edited files.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DirName is the name of the snapshot directory in the output directory.
+const DirName = ".render-snapshot"
+
+// Names of the entries inside the snapshot directory.
+const (
+	templateDir = "template"
+	partialsDir = "partials"
+	controlDir  = "control"
+	dataFile    = "data.json"
+	optionsFile = "options.json"
+)
+
+// Sources are the inputs of a render.
+type Sources struct {
+	TemplateDir string // Template directory
+	PartialsDir string // Shared partials directory, "" if none
+	ControlFile string // Explicit control file, "" if auto-discovered
+	Data        any    // Data as passed to the templates
+	SkipEmpty   bool   // Templates that rendered blank were left out
+}
+
+// options are the render options a snapshot records, in optionsFile.
+type options struct {
+	SkipEmpty bool `json:"skip_empty,omitempty"`
+}
+
+// Snapshot is a saved copy of a render's sources.
+type Snapshot struct {
+	dir string
+}
+
+// Save replaces the snapshot in outputDir with a copy of src.
+// The new snapshot is assembled beside the old one and swapped in, so a
+// failure leaves the previous snapshot intact.
+func Save(outputDir string, src Sources) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", outputDir, err)
+	}
+	tmp, err := os.MkdirTemp(outputDir, DirName+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	if err := copyDir(src.TemplateDir, filepath.Join(tmp, templateDir)); err != nil {
+		return fmt.Errorf("failed to snapshot templates: %w", err)
+	}
+	if src.PartialsDir != "" {
+		if err := copyDir(src.PartialsDir, filepath.Join(tmp, partialsDir)); err != nil {
+			return fmt.Errorf("failed to snapshot partials: %w", err)
+		}
+	}
+	if src.ControlFile != "" {
+		dst := filepath.Join(tmp, controlDir, filepath.Base(src.ControlFile))
+		if err := copyFile(src.ControlFile, dst); err != nil {
+			return fmt.Errorf("failed to snapshot control file: %w", err)
+		}
+	}
+
+	content, err := json.MarshalIndent(encodeNumbers(src.Data), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode data: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, dataFile), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to snapshot data: %w", err)
+	}
+
+	content, err = json.MarshalIndent(options{SkipEmpty: src.SkipEmpty}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode options: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, optionsFile), append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to snapshot options: %w", err)
+	}
+
+	dir := filepath.Join(outputDir, DirName)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load opens the snapshot in outputDir. The error wraps os.ErrNotExist if
+// there is none.
+func Load(outputDir string) (*Snapshot, error) {
+	dir := filepath.Join(outputDir, DirName)
+	info, err := os.Stat(filepath.Join(dir, templateDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no snapshot in %s: %w", outputDir, err)
+		}
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("snapshot %s is invalid: %s is not a directory", dir, templateDir)
+	}
+	return &Snapshot{dir: dir}, nil
+}
+
+// TemplateDir returns the snapshot's copy of the template directory.
+func (s *Snapshot) TemplateDir() string {
+	return filepath.Join(s.dir, templateDir)
+}
+
+// PartialsDir returns the snapshot's copy of the partials directory,
+// or "" if the render used none.
+func (s *Snapshot) PartialsDir() string {
+	dir := filepath.Join(s.dir, partialsDir)
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	return dir
+}
+
+// ControlFile returns the snapshot's copy of the explicit control file,
+// or "" if the render used none.
+func (s *Snapshot) ControlFile() string {
+	entries, err := os.ReadDir(filepath.Join(s.dir, controlDir))
+	if err != nil || len(entries) != 1 {
+		return ""
+	}
+	return filepath.Join(s.dir, controlDir, entries[0].Name())
+}
+
+// SkipEmpty reports whether the render left out templates that rendered
+// blank. Snapshots without options report false.
+func (s *Snapshot) SkipEmpty() (bool, error) {
+	content, err := os.ReadFile(filepath.Join(s.dir, optionsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read snapshot options: %w", err)
+	}
+	var opts options
+	if err := json.Unmarshal(content, &opts); err != nil {
+		return false, fmt.Errorf("failed to parse snapshot options: %w", err)
+	}
+	return opts.SkipEmpty, nil
+}
+
+// Data returns the data the templates were rendered with.
+func (s *Snapshot) Data() (any, error) {
+	content, err := os.ReadFile(filepath.Join(s.dir, dataFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot data: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var d any
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot data: %w", err)
+	}
+	return decodeNumbers(d), nil
+}
+
+// encodeNumbers returns a copy of v with every float written with a
+// fraction or exponent, so decodeNumbers can tell floats from integers
+// and the templates see the same types when the snapshot renders again.
+func encodeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = encodeNumbers(e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = encodeNumbers(e)
+		}
+		return l
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") && !math.IsInf(v, 0) && !math.IsNaN(v) {
+			s += ".0"
+		}
+		return json.Number(s)
+	}
+	return v
+}
+
+// decodeNumbers turns the numbers in v, decoded with UseNumber, back into
+// ints and float64s as encodeNumbers wrote them.
+func decodeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = decodeNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = decodeNumbers(e)
+		}
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			if i, err := strconv.Atoi(string(v)); err == nil {
+				return i
+			}
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// copyDir copies the regular files and directories under src to dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		switch {
+		case d.IsDir() && strings.HasPrefix(d.Name(), DirName):
+			// An output directory inside the template directory
+			return filepath.SkipDir
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			// Renders refuse symlinks, so there is nothing else to keep
+			return nil
+		}
+	})
+}
+
+// copyFile copies a regular file, keeping its permissions.
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, info.Mode().Perm())
+}
@@ -0,0 +1,131 @@
+package snapshot
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	tmplDir := filepath.Join(dir, "templates")
+	outDir := filepath.Join(dir, "out")
+
+	if err := os.MkdirAll(filepath.Join(tmplDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmplDir, "sub", "a.txt.tmpl"), []byte("{{ .v }}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := Sources{TemplateDir: tmplDir, Data: map[string]any{"v": "x"}}
+	if err := Save(outDir, src); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// Saving again replaces the snapshot
+	if err := Save(outDir, src); err != nil {
+		t.Fatalf("second Save failed: %v", err)
+	}
+
+	snap, err := Load(outDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	copied := filepath.Join(snap.TemplateDir(), "sub", "a.txt.tmpl")
+	info, err := os.Stat(copied)
+	if err != nil {
+		t.Fatalf("template not copied: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	d, err := snap.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	if m, ok := d.(map[string]any); !ok || m["v"] != "x" {
+		t.Errorf("Data = %v, want map with v=x", d)
+	}
+
+	if snap.PartialsDir() != "" || snap.ControlFile() != "" {
+		t.Error("Expected no partials or control file")
+	}
+	if skip, err := snap.SkipEmpty(); err != nil || skip {
+		t.Errorf("SkipEmpty() = %v, %v, want false", skip, err)
+	}
+
+	entries, _ := os.ReadDir(outDir)
+	if len(entries) != 1 || entries[0].Name() != DirName {
+		t.Errorf("Expected only %s in output, got %d entries", DirName, len(entries))
+	}
+}
+
+func TestSkipEmpty(t *testing.T) {
+	dir := t.TempDir()
+	tmplDir := filepath.Join(dir, "templates")
+	outDir := filepath.Join(dir, "out")
+	if err := os.MkdirAll(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Save(outDir, Sources{TemplateDir: tmplDir, SkipEmpty: true}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	snap, err := Load(outDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if skip, err := snap.SkipEmpty(); err != nil || !skip {
+		t.Errorf("SkipEmpty() = %v, %v, want true", skip, err)
+	}
+
+	// Snapshots saved before options were recorded have none
+	if err := os.Remove(filepath.Join(outDir, DirName, optionsFile)); err != nil {
+		t.Fatal(err)
+	}
+	if skip, err := snap.SkipEmpty(); err != nil || skip {
+		t.Errorf("SkipEmpty() without options = %v, %v, want false", skip, err)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(t.TempDir())
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() error = %v, want ErrNotExist", err)
+	}
+}
+
+func TestData_KeepsNumberTypes(t *testing.T) {
+	dir := t.TempDir()
+	tmplDir := filepath.Join(dir, "templates")
+	outDir := filepath.Join(dir, "out")
+	if err := os.MkdirAll(tmplDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	in := map[string]any{
+		"replicas": 1500000,
+		"ratio":    2.0,
+		"scale":    1e21,
+		"list":     []any{3, 0.5},
+	}
+	if err := Save(outDir, Sources{TemplateDir: tmplDir, Data: in}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	snap, err := Load(outDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	d, err := snap.Data()
+	if err != nil {
+		t.Fatalf("Data failed: %v", err)
+	}
+	if !reflect.DeepEqual(d, any(in)) {
+		t.Errorf("Data = %#v, want %#v", d, in)
+	}
+}
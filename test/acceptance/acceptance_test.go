@@ -4,6 +4,7 @@
 package acceptance
 
 import (
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -70,9 +71,29 @@ func (e *buildError) Error() string {
 // runRender executes the render binary with the given arguments.
 func runRender(t *testing.T, args ...string) (string, string, error) {
 	t.Helper()
+	return runRenderIn(t, "", args...)
+}
+
+// runRenderIn is runRender with the working directory set to dir.
+func runRenderIn(t *testing.T, dir string, args ...string) (string, string, error) {
+	t.Helper()
+	return runRenderCmd(t, dir, nil, args...)
+}
+
+// runRenderWithStdin is runRender with stdin as standard input.
+func runRenderWithStdin(t *testing.T, stdin string, args ...string) (string, string, error) {
+	t.Helper()
+	return runRenderCmd(t, "", strings.NewReader(stdin), args...)
+}
+
+// runRenderCmd executes the render binary in dir, reading stdin if not nil.
+func runRenderCmd(t *testing.T, dir string, stdin io.Reader, args ...string) (string, string, error) {
+	t.Helper()
 
 	binary := ensureBinary(t)
 	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	cmd.Stdin = stdin
 
 	var stdout, stderr strings.Builder
 	cmd.Stdout = &stdout
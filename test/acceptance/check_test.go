@@ -0,0 +1,140 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckDirectoryMode tests that --check reports created, changed and stale
+// files, exits with code 7 and never writes. Files render didn't write are
+// never stale.
+func TestCheckDirectoryMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "config.yaml.tmpl", "name: {{ .name }}")
+	writeFile(t, tmplDir, "static.txt", "static")
+	writeFile(t, tmplDir, "old.txt", "leftover")
+
+	data := writeFile(t, dir, "data.json", `{"name": "app"}`)
+	outputDir := filepath.Join(dir, "output")
+
+	// Nothing generated yet: everything would be created
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--check")
+	if code := getExitCode(err); code != 7 {
+		t.Fatalf("exit code = %d, want 7\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "[create]") {
+		t.Errorf("expected create actions: %s", stdout)
+	}
+	if fileExists(outputDir) {
+		t.Fatal("--check should not write anything")
+	}
+
+	// Freshly generated output is up to date
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--check"); err != nil {
+		t.Fatalf("expected up to date: %v\nstderr: %s", err, stderr)
+	}
+
+	// A hand-written file is not render's to report
+	writeFile(t, outputDir, "NOTES.md", "notes")
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--check"); err != nil {
+		t.Fatalf("expected up to date with NOTES.md: %v\nstderr: %s", err, stderr)
+	}
+
+	// Changed data and a template no longer generated make it out of date
+	if err := os.Remove(filepath.Join(tmplDir, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	data = writeFile(t, dir, "data.json", `{"name": "api"}`)
+	stdout, stderr, err = runRender(t, tmplDir, data, "-o", outputDir, "--check", "--json")
+	if code := getExitCode(err); code != 7 {
+		t.Fatalf("exit code = %d, want 7\nstderr: %s", code, stderr)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Files  []struct {
+			Path   string `json:"path"`
+			Action string `json:"action"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Status != "out-of-date" {
+		t.Errorf("status = %q, want out-of-date", result.Status)
+	}
+	actions := make(map[string]string)
+	for _, f := range result.Files {
+		actions[filepath.Base(f.Path)] = f.Action
+	}
+	want := map[string]string{"config.yaml": "change", "static.txt": "unchanged", "old.txt": "stale"}
+	for name, action := range want {
+		if actions[name] != action {
+			t.Errorf("%s action = %q, want %q", name, actions[name], action)
+		}
+	}
+	if _, ok := actions["NOTES.md"]; ok {
+		t.Errorf("NOTES.md should not be reported: %s", stdout)
+	}
+	if content := readFile(t, filepath.Join(outputDir, "config.yaml")); content != "name: app" {
+		t.Errorf("--check modified output: %q", content)
+	}
+}
+
+// TestCheckEachFileMode tests --check with one output per item.
+func TestCheckEachFileMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .name }}")
+	data := writeFile(t, dir, "data.json", `[{"name": "alice"}, {"name": "bob"}]`)
+	outputDir := filepath.Join(dir, "users")
+
+	if _, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(outputDir, "{{ .name }}.txt")); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "bob.txt"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(outputDir, "{{ .name }}.txt"), "--check")
+	if code := getExitCode(err); code != 7 {
+		t.Fatalf("exit code = %d, want 7\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "[change] "+filepath.Join(outputDir, "bob.txt")) {
+		t.Errorf("expected bob.txt to be changed: %s", stdout)
+	}
+	if strings.Contains(stdout, "alice.txt") {
+		t.Errorf("unchanged files should not be listed: %s", stdout)
+	}
+}
+
+// TestCheckEachFileModeStale tests that --check reports the outputs of
+// removed items as stale.
+func TestCheckEachFileModeStale(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .name }}")
+	data := writeFile(t, dir, "data.json", `[{"name": "alice"}, {"name": "bob"}]`)
+	outputPath := filepath.Join(dir, "users", "{{ .name }}.txt")
+
+	if _, stderr, err := runRender(t, tmpl, data, "-o", outputPath); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	data = writeFile(t, dir, "data.json", `[{"name": "alice"}]`)
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", outputPath, "--check")
+	if code := getExitCode(err); code != 7 {
+		t.Fatalf("exit code = %d, want 7\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "[stale] "+filepath.Join(dir, "users", "bob.txt")) {
+		t.Errorf("expected bob.txt to be stale: %s", stdout)
+	}
+}
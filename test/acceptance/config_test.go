@@ -489,3 +489,101 @@ func TestConfigMixedPathFormats(t *testing.T) {
 		}
 	}
 }
+
+// TestConfigWhen tests that when: conditions exclude files and whole
+// directories, reported as skipped (condition).
+func TestConfigWhen(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "Dockerfile.tmpl", "FROM {{ .docker.image }}")
+	writeFile(t, tmplDir, "deploy/app.yaml.tmpl", "name: {{ .name }}")
+	writeFile(t, tmplDir, ".render.yaml", `paths:
+  "Dockerfile.tmpl":
+    when: "{{ .docker.enabled }}"
+  "deploy":
+    path: "k8s"
+    when: "{{ .deploy }}"
+`)
+	outputDir := filepath.Join(dir, "output")
+
+	off := writeFile(t, dir, "off.yaml", "name: api\ndocker:\n  enabled: false\n")
+	stdout, stderr, err := runRender(t, tmplDir, off, "-o", outputDir, "--dry-run")
+	if err != nil {
+		t.Fatalf("Render failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{
+		"[skipped (condition)] " + filepath.Join(outputDir, "Dockerfile"),
+		"[skipped (condition)] " + filepath.Join(outputDir, "deploy"),
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in dry-run output:\n%s", want, stdout)
+		}
+	}
+
+	if _, stderr, err = runRender(t, tmplDir, off, "-o", outputDir); err != nil {
+		t.Fatalf("Render failed: %v\nstderr: %s", err, stderr)
+	}
+	if !fileExists(filepath.Join(outputDir, "README.md")) {
+		t.Error("README.md should be rendered")
+	}
+	for _, name := range []string{"Dockerfile", "deploy", "k8s"} {
+		if fileExists(filepath.Join(outputDir, name)) {
+			t.Errorf("%s should have been excluded", name)
+		}
+	}
+
+	on := writeFile(t, dir, "on.yaml", "name: api\ndeploy: true\ndocker:\n  enabled: true\n  image: alpine\n")
+	if _, stderr, err = runRender(t, tmplDir, on, "-o", outputDir, "--force"); err != nil {
+		t.Fatalf("Render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "Dockerfile")); got != "FROM alpine" {
+		t.Errorf("Dockerfile = %q", got)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "k8s", "app.yaml")); got != "name: api" {
+		t.Errorf("k8s/app.yaml = %q", got)
+	}
+}
+
+// TestSkipEmpty tests that --skip-empty and skipEmpty: leave out templates
+// that render blank, reported as skipped (empty).
+func TestSkipEmpty(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "metrics.go.tmpl", "{{ if .metrics }}package metrics{{ end }}\n")
+	writeFile(t, tmplDir, "notes.txt.tmpl", "{{ .notes }}")
+	writeFile(t, tmplDir, ".render.yaml", `paths:
+  "notes.txt.tmpl":
+    skipEmpty: true
+`)
+	data := writeFile(t, dir, "data.yaml", "name: api\nnotes: \"\"\n")
+	outputDir := filepath.Join(dir, "output")
+
+	// skipEmpty: applies without the flag
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--json")
+	if err != nil {
+		t.Fatalf("Render failed: %v\nstderr: %s", err, stderr)
+	}
+	if !fileExists(filepath.Join(outputDir, "metrics.go")) || fileExists(filepath.Join(outputDir, "notes.txt")) {
+		t.Error("only notes.txt should be skipped without --skip-empty")
+	}
+	if !strings.Contains(stdout, `"skipped (empty)"`) {
+		t.Errorf("expected notes.txt to be reported as skipped (empty): %s", stdout)
+	}
+
+	outputDir = filepath.Join(dir, "all")
+	stdout, stderr, err = runRender(t, tmplDir, data, "-o", outputDir, "--skip-empty", "--dry-run")
+	if err != nil {
+		t.Fatalf("Render failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "[skipped (empty)] "+filepath.Join(outputDir, "metrics.go")) {
+		t.Errorf("expected metrics.go to be skipped in the dry run:\n%s", stdout)
+	}
+
+	tmpl := writeFile(t, dir, "single.tmpl", "")
+	_, stderr, err = runRender(t, tmpl, data, "-o", filepath.Join(dir, "single.txt"), "--skip-empty")
+	if code := getExitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2\nstderr: %s", code, stderr)
+	}
+}
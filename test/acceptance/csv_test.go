@@ -0,0 +1,91 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCSVEachMode tests that each CSV row drives each mode, with typed
+// columns for --item-query filtering.
+func TestCSVEachMode(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "user.txt.tmpl", "{{ .name }} {{ .age }} {{ .zip }}")
+	data := writeFile(t, dir, "users.csv", "name,age,active,zip\nann,31,true,02134\nbob,9,false,10001\ncid,40,true,94103\n")
+	outputDir := filepath.Join(dir, "users")
+
+	_, stderr, err := runRender(t, tmpl, data,
+		"--csv-infer", "--csv-types", "zip=string",
+		"--item-query", ".[] | select(.active and .age > 18)",
+		"-o", filepath.Join(outputDir, "{{ .name }}.txt"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	if got := readFile(t, filepath.Join(outputDir, "ann.txt")); got != "ann 31 02134" {
+		t.Errorf("ann.txt = %q, want %q", got, "ann 31 02134")
+	}
+	if got := readFile(t, filepath.Join(outputDir, "cid.txt")); got != "cid 40 94103" {
+		t.Errorf("cid.txt = %q, want %q", got, "cid 40 94103")
+	}
+	if fileExists(filepath.Join(outputDir, "bob.txt")) {
+		t.Error("bob.txt should be filtered out")
+	}
+}
+
+// TestTSVWithoutHeader tests TSV data with column names from the command
+// line and a custom delimiter.
+func TestTSVWithoutHeader(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "hosts.tmpl", "{{ range . }}{{ .host }}:{{ .port }}\n{{ end }}")
+	output := filepath.Join(dir, "hosts.txt")
+
+	tsv := writeFile(t, dir, "hosts.tsv", "db\t5432\ncache\t6379\n")
+	_, stderr, err := runRender(t, tmpl, tsv, "--csv-columns", "host,port", "--csv-no-header", "-o", output)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, output), "db:5432\ncache:6379\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	semi := writeFile(t, dir, "hosts.csv", "host;port\ndb;5432\n")
+	_, stderr, err = runRender(t, tmpl, semi, "--csv-delimiter", ";", "-o", output, "--force")
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, output), "db:5432\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+// TestCSVErrors tests errors for invalid CSV options and values.
+func TestCSVErrors(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "a.tmpl", "x")
+	data := writeFile(t, dir, "data.csv", "name,age\nann,x\n")
+	output := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"bad delimiter", []string{"--csv-delimiter", "ab"}, 2, "invalid --csv-delimiter"},
+		{"bad value", []string{"--csv-types", "age=int"}, 3, `line 2, column "age": invalid int "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{tmpl, data, "-o", output}, tt.args...)
+			_, stderr, err := runRender(t, args...)
+			if code := getExitCode(err); code != tt.code {
+				t.Errorf("exit code = %d, want %d\nstderr: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr: %s", tt.want, stderr)
+			}
+		})
+	}
+}
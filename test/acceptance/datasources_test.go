@@ -0,0 +1,146 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDataFromStdin tests reading data from standard input with "-".
+func TestDataFromStdin(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "pods.txt.tmpl", "{{ range .items }}{{ .name }}\n{{ end }}")
+	output := filepath.Join(dir, "pods.txt")
+
+	// JSON is detected from the content
+	_, stderr, err := runRenderWithStdin(t, `{"items": [{"name": "a"}, {"name": "b"}]}`, tmpl, "-", "-o", output)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, output); got != "a\nb\n" {
+		t.Errorf("output = %q, want %q", got, "a\nb\n")
+	}
+
+	_, stderr, err = runRenderWithStdin(t, "items:\n  - name: c\n", tmpl, "-", "--data-format", "yaml", "-o", output, "--force")
+	if err != nil {
+		t.Fatalf("render with --data-format failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, output); got != "c\n" {
+		t.Errorf("output = %q, want %q", got, "c\n")
+	}
+}
+
+// TestDataLayered tests that data sources are deep-merged in order.
+func TestDataLayered(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "config.tmpl", "{{ .db.host }}:{{ .db.port }}{{ range .services }} {{ .name }}={{ .replicas }}{{ end }}\n")
+	base := writeFile(t, dir, "base.yaml", `db:
+  host: localhost
+  port: 5432
+services:
+  - name: api
+    replicas: 1
+  - name: web
+    replicas: 1
+`)
+	prod := writeFile(t, dir, "prod.yaml", `db:
+  host: prod-db
+services:
+  - name: web
+    replicas: 3
+`)
+	output := filepath.Join(dir, "config.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"replace lists", []string{base, prod}, "prod-db:5432 web=3\n"},
+		{"append lists", []string{base, "--data", prod, "--list-merge", "append"}, "prod-db:5432 api=1 web=1 web=3\n"},
+		{"merge lists by key", []string{base, prod, "--list-merge", "merge-by-key"}, "prod-db:5432 api=1 web=3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{tmpl}, tt.args...)
+			args = append(args, "-o", output, "--force")
+			if _, stderr, err := runRender(t, args...); err != nil {
+				t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+			}
+			if got := readFile(t, output); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDataDirectory tests a directory of data files as one data source,
+// keyed by path or listed with _file.
+func TestDataDirectory(t *testing.T) {
+	dir := createTempDir(t)
+	services := filepath.Join(dir, "services")
+	writeFile(t, services, "api.yaml", "port: 8080\n")
+	writeFile(t, services, "web.json", `{"port": 80}`)
+	writeFile(t, services, "batch/report.yaml", "port: 0\n")
+	writeFile(t, services, "README.md", "# Services")
+	outputDir := filepath.Join(dir, "out")
+
+	tmpl := writeFile(t, dir, "all.tmpl", "{{ range $k, $v := . }}{{ $k }}={{ $v.port }}\n{{ end }}")
+	_, stderr, err := runRender(t, tmpl, services, "-o", filepath.Join(outputDir, "all.txt"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, filepath.Join(outputDir, "all.txt")), "api=8080\nbatch/report=0\nweb=80\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// Each mode over the listed documents
+	each := writeFile(t, dir, "service.tmpl", "{{ ._file }}:{{ .port }}")
+	_, stderr, err = runRender(t, each, services, "--dir-layout", "list",
+		"--item-query", ".[] | select(.port > 0)",
+		"-o", filepath.Join(outputDir, "{{ ._file }}.txt"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "api.yaml.txt")); got != "api.yaml:8080" {
+		t.Errorf("api.yaml.txt = %q", got)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "web.json.txt")); got != "web.json:80" {
+		t.Errorf("web.json.txt = %q", got)
+	}
+	if fileExists(filepath.Join(outputDir, "report.yaml.txt")) {
+		t.Error("report.yaml.txt should be filtered out")
+	}
+}
+
+// TestDataSourceErrors tests usage errors for data sources.
+func TestDataSourceErrors(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "a.tmpl", "x")
+	output := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no data", []string{tmpl, "-o", output}, "requires a data source"},
+		{"stdin twice", []string{tmpl, "-", "--data", "-", "-o", output}, "only be used as one"},
+		{"format without stdin", []string{tmpl, writeFile(t, dir, "d.json", "{}"), "--data-format", "json", "-o", output}, "--data-format requires"},
+		{"unknown strategy", []string{tmpl, "-", "--list-merge", "zip", "-o", output}, "unknown list merge strategy"},
+		{"unknown layout", []string{tmpl, "-", "--dir-layout", "tree", "-o", output}, "unknown directory layout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := runRenderWithStdin(t, "{}", tt.args...)
+			if code := getExitCode(err); code != 2 {
+				t.Errorf("exit code = %d, want 2\nstderr: %s", code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr: %s", tt.want, stderr)
+			}
+		})
+	}
+}
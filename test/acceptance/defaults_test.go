@@ -0,0 +1,94 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// TestDefaultsFile tests that the defaults file the control file names fills
+// in keys the data omits, is not copied, and is checked by the schema
+// together with the data.
+func TestDefaultsFile(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "app.conf.tmpl", "{{ .name }}:{{ .port }} {{ .db.host }}/{{ .db.name }}")
+	writeFile(t, tmplDir, "defaults.yaml", "port: 8080\ndb:\n  host: localhost\n  name: app\n")
+	writeFile(t, tmplDir, "render.schema.json", serviceSchema)
+	writeFile(t, tmplDir, ".render.yaml", "schema: render.schema.json\ndefaults: defaults.yaml\n")
+	data := writeFile(t, dir, "values.yaml", "name: api\ndb:\n  name: orders\n")
+	outputDir := filepath.Join(dir, "out")
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, filepath.Join(outputDir, "app.conf")), "api:8080 localhost/orders"; got != want {
+		t.Errorf("app.conf = %q, want %q", got, want)
+	}
+	if fileExists(filepath.Join(outputDir, "defaults.yaml")) {
+		t.Error("defaults.yaml should not be copied to the output")
+	}
+}
+
+// TestDefaultsDryRun tests that the defaults: section of the control file
+// applies to every item and is shown in the --dry-run JSON.
+func TestDefaultsDryRun(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }} ({{ .tier }})")
+	writeFile(t, tmplDir, ".render.yaml", "defaults:\n  tier: free\n")
+	data := writeFile(t, dir, "services.yaml", "- name: api\n- name: web\n  tier: pro\n")
+	outputDir := filepath.Join(dir, "out")
+
+	stdout, stderr, err := runRender(t, tmplDir, data, "--dry-run", "--json", "-o", filepath.Join(outputDir, "{{ .name }}"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	var result struct {
+		Status   string         `json:"status"`
+		Defaults map[string]any `json:"defaults"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if result.Status != "dry-run" || result.Defaults["tier"] != "free" {
+		t.Errorf("expected the defaults in the dry-run result: %s", stdout)
+	}
+
+	_, stderr, err = runRender(t, tmplDir, data, "-o", filepath.Join(outputDir, "{{ .name }}"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	want := map[string]string{"api": "# api (free)", "web": "# web (pro)"}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(outputDir, name, "README.md")); got != content {
+			t.Errorf("%s/README.md = %q, want %q", name, got, content)
+		}
+	}
+}
+
+// TestDefaultsFileNotNamed tests that a defaults.yaml the control file
+// doesn't name is an ordinary file: it is copied and supplies no values.
+func TestDefaultsFileNotNamed(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "defaults.yaml", "name: api\n")
+	writeFile(t, tmplDir, "render.schema.json", serviceSchema)
+	data := writeFile(t, dir, "values.yaml", "name: app\n")
+	outputDir := filepath.Join(dir, "out")
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, name := range []string{"defaults.yaml", "render.schema.json"} {
+		if !fileExists(filepath.Join(outputDir, name)) {
+			t.Errorf("%s should be copied to the output", name)
+		}
+	}
+	if got, want := readFile(t, filepath.Join(outputDir, "README.md")), "# app"; got != want {
+		t.Errorf("README.md = %q, want %q", got, want)
+	}
+}
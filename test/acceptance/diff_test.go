@@ -0,0 +1,130 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDryRunDiff tests that --dry-run --diff shows a unified diff of changed
+// files and a summary, without writing.
+func TestDryRunDiff(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "config.yaml.tmpl", "name: {{ .name }}\nport: 8080\n")
+	writeFile(t, tmplDir, "static.txt", "static\n")
+
+	outputDir := filepath.Join(dir, "output")
+	data := writeFile(t, dir, "data.json", `{"name": "app"}`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	writeFile(t, tmplDir, "new.txt.tmpl", "{{ .name }}\n")
+	data = writeFile(t, dir, "data.json", `{"name": "api"}`)
+
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--dry-run", "--diff")
+	if err != nil {
+		t.Fatalf("render --dry-run --diff failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{
+		"--- " + filepath.Join(outputDir, "config.yaml"),
+		"-name: app\n+name: api\n port: 8080\n",
+		"--- /dev/null\n+++ " + filepath.Join(outputDir, "new.txt"),
+		"Dry run: 1 new, 1 changed, 1 unchanged",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	if content := readFile(t, filepath.Join(outputDir, "config.yaml")); content != "name: app\nport: 8080\n" {
+		t.Errorf("--dry-run --diff modified output: %q", content)
+	}
+	if fileExists(filepath.Join(outputDir, "new.txt")) {
+		t.Error("--dry-run --diff created a file")
+	}
+
+	stdout, stderr, err = runRender(t, tmplDir, data, "-o", outputDir, "--dry-run", "--diff", "--json")
+	if err != nil {
+		t.Fatalf("render --json failed: %v\nstderr: %s", err, stderr)
+	}
+	var result struct {
+		Files []struct {
+			Path   string `json:"path"`
+			Action string `json:"action"`
+			Diff   string `json:"diff"`
+		} `json:"files"`
+		Summary struct {
+			New       int `json:"new"`
+			Changed   int `json:"changed"`
+			Unchanged int `json:"unchanged"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Summary.New != 1 || result.Summary.Changed != 1 || result.Summary.Unchanged != 1 {
+		t.Errorf("summary = %+v", result.Summary)
+	}
+	for _, f := range result.Files {
+		if filepath.Base(f.Path) == "config.yaml" && !strings.Contains(f.Diff, "+name: api") {
+			t.Errorf("config.yaml diff = %q", f.Diff)
+		}
+	}
+}
+
+// TestDryRunDiffSkippedAndPruned tests that --dry-run --diff lists the
+// template entries left out and shows --prune deletions as diffs.
+func TestDryRunDiffSkippedAndPruned(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}\n")
+	writeFile(t, tmplDir, "old.txt", "old\n")
+	writeFile(t, tmplDir, "Dockerfile.tmpl", "FROM {{ .image }}\n")
+	writeFile(t, tmplDir, "extra.txt.tmpl", "{{ if .extra }}extra{{ end }}\n")
+	writeFile(t, tmplDir, ".render.yaml", "paths:\n  \"Dockerfile.tmpl\":\n    when: \"{{ .docker }}\"\n")
+
+	outputDir := filepath.Join(dir, "output")
+	data := writeFile(t, dir, "data.json", `{"name": "api", "docker": false}`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--skip-empty"); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	if err := os.Remove(filepath.Join(tmplDir, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--skip-empty", "--prune", "--dry-run", "--diff")
+	if err != nil {
+		t.Fatalf("render --dry-run --diff failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{
+		"--- " + filepath.Join(outputDir, "old.txt") + "\n+++ /dev/null\n@@ -1 +0,0 @@\n-old\n",
+		"[skipped (condition)] " + filepath.Join(outputDir, "Dockerfile"),
+		"[skipped (empty)] " + filepath.Join(outputDir, "extra.txt"),
+		"Dry run: 0 new, 0 changed, 1 unchanged, 1 deleted, 2 skipped",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout missing %q:\n%s", want, stdout)
+		}
+	}
+	if !fileExists(filepath.Join(outputDir, "old.txt")) {
+		t.Error("--dry-run --diff deleted a file")
+	}
+}
+
+// TestDiffRequiresDryRun tests that --diff alone is a usage error.
+func TestDiffRequiresDryRun(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "template.txt", "Hello!")
+	data := writeFile(t, dir, "data.json", `{}`)
+
+	_, _, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "out.txt"), "--diff")
+	if code := getExitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
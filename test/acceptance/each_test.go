@@ -1,6 +1,7 @@
 package acceptance
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -554,3 +555,112 @@ func TestItemQueryEmptyResult(t *testing.T) {
 		}
 	}
 }
+
+// TestEachJobsPreservesOrder tests that --jobs renders all items and reports them in item order.
+func TestEachJobsPreservesOrder(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "item.txt.tmpl", "item {{ .id }}")
+
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 50; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(fmt.Sprintf(`{"id": %d}`, i))
+	}
+	sb.WriteString("]")
+	data := writeFile(t, dir, "data.json", sb.String())
+
+	outputPattern := filepath.Join(dir, "output", "{{.id}}.txt")
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", outputPattern, "--jobs", "8", "--json")
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	var result struct {
+		Files []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(result.Files) != 50 {
+		t.Fatalf("expected 50 files, got %d", len(result.Files))
+	}
+	for i, f := range result.Files {
+		want := filepath.Join(dir, "output", fmt.Sprintf("%d.txt", i))
+		if f.Path != want {
+			t.Errorf("files[%d] = %s, want %s", i, f.Path, want)
+		}
+		if got := readFile(t, want); got != fmt.Sprintf("item %d", i) {
+			t.Errorf("%s content = %q", want, got)
+		}
+	}
+}
+
+// TestEachJobsCollisionIsDeterministic tests that collisions are reported by item index under --jobs.
+func TestEachJobsCollisionIsDeterministic(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "item.txt.tmpl", "{{ .id }}")
+	data := writeFile(t, dir, "data.json", `[
+		{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "a"},
+		{"id": 4, "name": "c"}, {"id": 5, "name": "b"}
+	]`)
+
+	outputPattern := filepath.Join(dir, "output", "{{.name}}.txt")
+
+	for run := 0; run < 3; run++ {
+		_, stderr, err := runRender(t, tmpl, data, "-o", outputPattern, "--jobs", "4")
+		if err == nil {
+			t.Fatal("expected collision error")
+		}
+		if !strings.Contains(stderr, "index 0 and 2") {
+			t.Errorf("run %d: expected first collision between items 0 and 2, got: %s", run, stderr)
+		}
+	}
+}
+
+// TestEachDirJobs tests --jobs with a directory template.
+func TestEachDirJobs(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "_partials/helpers.tmpl", `{{ define "pkg" }}package {{ .name }}{{ end }}`)
+	writeFile(t, tmplDir, "main.go.tmpl", `{{ template "pkg" . }}`)
+	writeFile(t, tmplDir, "static.txt", "static")
+
+	data := writeFile(t, dir, "data.json", `[{"name": "a"}, {"name": "b"}, {"name": "c"}, {"name": "d"}]`)
+	outputPattern := filepath.Join(dir, "output", "{{.name}}")
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputPattern, "-j", "0")
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if got := readFile(t, filepath.Join(dir, "output", name, "main.go")); got != "package "+name {
+			t.Errorf("%s/main.go = %q", name, got)
+		}
+		if !fileExists(filepath.Join(dir, "output", name, "static.txt")) {
+			t.Errorf("%s/static.txt not copied", name)
+		}
+	}
+}
+
+// TestEachJobsNegative tests that a negative --jobs value is a usage error.
+func TestEachJobsNegative(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "item.txt.tmpl", "{{ .id }}")
+	data := writeFile(t, dir, "data.json", `[{"id": 1}]`)
+
+	_, _, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "{{.id}}.txt"), "--jobs", "-1")
+	if code := getExitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
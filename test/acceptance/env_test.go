@@ -0,0 +1,85 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnvPrefix tests environment variables as a data source.
+func TestEnvPrefix(t *testing.T) {
+	t.Setenv("RENDERTEST_NAME", "svc")
+	t.Setenv("RENDERTEST_DB__HOST", "prod-db")
+
+	dir := createTempDir(t)
+	data := writeFile(t, dir, "data.yaml", "name: app\ndb:\n  host: localhost\n  port: 5432\n")
+	output := filepath.Join(dir, "out.txt")
+
+	t.Run("under env", func(t *testing.T) {
+		tmpl := writeFile(t, dir, "env.tmpl", "{{ .name }} {{ .env.name }} {{ .env.db.host }}")
+		_, stderr, err := runRender(t, tmpl, data, "--env-prefix", "RENDERTEST_", "-o", output, "--force")
+		if err != nil {
+			t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+		}
+		if got, want := readFile(t, output), "app svc prod-db"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("at the root", func(t *testing.T) {
+		tmpl := writeFile(t, dir, "root.tmpl", "{{ .name }} {{ .db.host }}:{{ .db.port }}")
+		_, stderr, err := runRender(t, tmpl, data, "--env-prefix", "RENDERTEST_", "--env-root", "-o", output, "--force")
+		if err != nil {
+			t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+		}
+		if got, want := readFile(t, output), "svc prod-db:5432"; got != want {
+			t.Errorf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("without a data file", func(t *testing.T) {
+		tmpl := writeFile(t, dir, "only.tmpl", "{{ .name }}")
+		_, stderr, err := runRender(t, tmpl, "--env-prefix", "RENDERTEST_", "--env-root", "-o", output, "--force")
+		if err != nil {
+			t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+		}
+		if got := readFile(t, output); got != "svc" {
+			t.Errorf("output = %q, want %q", got, "svc")
+		}
+	})
+
+	t.Run("env-root requires prefix", func(t *testing.T) {
+		tmpl := writeFile(t, dir, "only.tmpl", "{{ .name }}")
+		_, _, err := runRender(t, tmpl, data, "--env-root", "-o", output, "--force")
+		if code := getExitCode(err); code != 2 {
+			t.Errorf("exit code = %d, want 2", code)
+		}
+	})
+}
+
+// TestEnvFunction tests that the env template function is disabled unless
+// --allow-env is given.
+func TestEnvFunction(t *testing.T) {
+	t.Setenv("RENDERTEST_TOKEN", "secret")
+
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "token.tmpl", `{{ env "RENDERTEST_TOKEN" }}`)
+	data := writeFile(t, dir, "data.json", "{}")
+	output := filepath.Join(dir, "token.txt")
+
+	_, stderr, err := runRender(t, tmpl, data, "-o", output)
+	if code := getExitCode(err); code != 1 {
+		t.Errorf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "--allow-env") {
+		t.Errorf("expected hint about --allow-env: %s", stderr)
+	}
+
+	_, stderr, err = runRender(t, tmpl, data, "-o", output, "--allow-env")
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, output); got != "secret" {
+		t.Errorf("output = %q, want %q", got, "secret")
+	}
+}
@@ -1,6 +1,7 @@
 package acceptance
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -136,9 +137,9 @@ func TestUnsupportedDataFormat(t *testing.T) {
 		name string
 		ext  string
 	}{
-		{"xml", "data.xml"},
+		{"ini", "data.ini"},
 		{"txt", "data.txt"},
-		{"toml", "data.toml"},
+		{"properties", "data.properties"},
 		{"no extension", "data"},
 	}
 
@@ -402,3 +403,91 @@ func TestEmptyDataFile(t *testing.T) {
 		t.Log("Note: empty data file was accepted (might be valid depending on implementation)")
 	}
 }
+
+// TestTemplateErrorLocation tests that template errors name the file, line and show the source.
+func TestTemplateErrorLocation(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "ok.txt.tmpl", "{{ .name }}")
+	writeFile(t, tmplDir, "sub/bad.txt.tmpl", "first\nsecond {{ .name | nonexistent }}\nthird\n")
+	data := writeFile(t, dir, "data.json", `{"name": "x"}`)
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", filepath.Join(dir, "output"))
+	if err == nil {
+		t.Fatal("should fail with invalid template")
+	}
+
+	for _, want := range []string{"sub/bad.txt.tmpl:2", "> 2 | second {{ .name | nonexistent }}", "  3 | third"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+}
+
+// TestEachErrorItemPath tests that each-mode errors report the item index and jq path.
+func TestEachErrorItemPath(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .name }} {{ div 10 .count }}")
+	data := writeFile(t, dir, "data.json", `{"users": [
+		{"name": "a", "count": 1, "active": true},
+		{"name": "b", "count": 0, "active": false},
+		{"name": "c", "count": 0, "active": true}
+	]}`)
+
+	_, stderr, err := runRender(t, tmpl, data, "--item-query", ".users[] | select(.active)", "-o", filepath.Join(dir, "{{.name}}.txt"))
+	if err == nil {
+		t.Fatal("should fail on division by zero")
+	}
+	if !strings.Contains(stderr, "item 1 (.users[2])") {
+		t.Errorf("stderr should name the item index and jq path: %s", stderr)
+	}
+}
+
+// TestJSONErrorObject tests that --json reports failures as a typed error object.
+func TestJSONErrorObject(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "item.tmpl", "id: {{ .id }}\nport: {{ .port }}\n")
+	data := writeFile(t, dir, "data.json", `[{"id": "a", "port": 1}, {"id": "b"}]`)
+
+	stdout, _, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "{{.id}}.txt"), "--strict", "--json")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Error  struct {
+			Message  string `json:"message"`
+			Code     int    `json:"code"`
+			Template string `json:"template"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+			Snippet  string `json:"snippet"`
+			Item     *int   `json:"item"`
+			ItemPath string `json:"itemPath"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+
+	if result.Status != "error" {
+		t.Errorf("status = %q, want error", result.Status)
+	}
+	e := result.Error
+	if e.Code != 1 || e.Template != "item.tmpl" || e.Line != 2 || e.Column == 0 {
+		t.Errorf("error location = %+v", e)
+	}
+	if e.Item == nil || *e.Item != 1 || e.ItemPath != ".[1]" {
+		t.Errorf("error item = %v %q, want 1 .[1]", e.Item, e.ItemPath)
+	}
+	if !strings.Contains(e.Snippet, "> 2 | port: {{ .port }}") {
+		t.Errorf("snippet = %q", e.Snippet)
+	}
+	if !strings.Contains(e.Message, `missing key "port"`) {
+		t.Errorf("message = %q", e.Message)
+	}
+}
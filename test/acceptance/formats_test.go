@@ -0,0 +1,83 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestDataFormats tests that TOML, HCL and XML data files work like JSON
+// with --query and templates.
+func TestDataFormats(t *testing.T) {
+	dir := createTempDir(t)
+
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		query string
+		tmpl  string
+		want  string
+	}{
+		{
+			name: "toml",
+			file: "Cargo.toml",
+			data: `[package]
+name = "app"
+version = "0.1.0"
+
+[[bin]]
+name = "server"
+
+[[bin]]
+name = "cli"
+`,
+			query: ".package + {bins: [.bin[].name]}",
+			tmpl:  `{{ .name }} {{ .version }} {{ join "," .bins }}`,
+			want:  "app 0.1.0 server,cli",
+		},
+		{
+			name: "tfvars",
+			file: "prod.tfvars",
+			data: `region = "eu-west-1"
+instance_count = 3
+tags = { team = "platform" }
+`,
+			tmpl: `{{ .region }} {{ .instance_count }} {{ .tags.team }}`,
+			want: "eu-west-1 3 platform",
+		},
+		{
+			name: "xml",
+			file: "pom.xml",
+			data: `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <artifactId>parent</artifactId>
+  <modules>
+    <module>api</module>
+    <module>server</module>
+  </modules>
+</project>
+`,
+			query: ".project",
+			tmpl:  `{{ .artifactId }}:{{ range .modules.module }} {{ . }}{{ end }}`,
+			want:  "parent: api server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeFile(t, dir, tt.file, tt.data)
+			tmpl := writeFile(t, dir, tt.name+".tmpl", tt.tmpl)
+			output := filepath.Join(dir, tt.name+".txt")
+
+			args := []string{tmpl, data, "-o", output}
+			if tt.query != "" {
+				args = append(args, "--query", tt.query)
+			}
+			if _, stderr, err := runRender(t, args...); err != nil {
+				t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+			}
+			if got := readFile(t, output); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestKeepGoingEachDirectory tests that --keep-going reports every failing
+// item and template, grouped by template, and writes nothing.
+func TestKeepGoingEachDirectory(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .name }} {{ .extra.value }}")
+	writeFile(t, tmplDir, "b.txt.tmpl", "{{ .name }} {{ .other.value }}")
+	writeFile(t, tmplDir, "ok.txt.tmpl", "{{ .name }}")
+
+	data := writeFile(t, dir, "data.json", `[{"name": "one"}, {"name": "two", "extra": {"value": 1}}]`)
+	outputDir := filepath.Join(dir, "output")
+
+	// Without --keep-going only the first failure is reported
+	_, stderr, err := runRender(t, tmplDir, data, "-o", filepath.Join(outputDir, "{{ .name }}"), "--strict")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if strings.Contains(stderr, "b.txt.tmpl") {
+		t.Errorf("expected only the first failure without --keep-going: %s", stderr)
+	}
+
+	_, stderr, err = runRender(t, tmplDir, data, "-o", filepath.Join(outputDir, "{{ .name }}"), "--strict", "--keep-going")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	for _, want := range []string{
+		"rendering failed with 3 error(s) in 2 template(s)",
+		"a.txt.tmpl:\n  - item 0 (.[0]):",
+		"b.txt.tmpl:\n  - item 0 (.[0]):",
+		"  - item 1 (.[1]):",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q: %s", want, stderr)
+		}
+	}
+	if fileExists(outputDir) {
+		t.Error("nothing should be written when any item fails")
+	}
+}
+
+// TestKeepGoingEachFile tests --keep-going with output path and collision errors.
+func TestKeepGoingEachFile(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .name }}")
+	data := writeFile(t, dir, "data.json", `[{"name": "a", "id": "1"}, {"name": "b"}, {"name": "c", "id": "1"}]`)
+	outputDir := filepath.Join(dir, "output")
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(outputDir, "{{ .id }}.txt"), "--strict", "--keep-going", "--json")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Errors []struct {
+			Message string `json:"message"`
+			Source  string `json:"source"`
+			Item    *int   `json:"item"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", result.Errors)
+	}
+	if e := result.Errors[0]; e.Source != "--output" || e.Item == nil || *e.Item != 1 {
+		t.Errorf("first error = %+v, want missing key in --output for item 1", e)
+	}
+	if e := result.Errors[1]; !strings.Contains(e.Message, "internal collision") {
+		t.Errorf("second error = %+v, want internal collision", e)
+	}
+	if fileExists(outputDir) {
+		t.Error("nothing should be written when any item fails")
+	}
+}
@@ -0,0 +1,118 @@
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPartialsInTemplateDir tests that the .tmpl files under _partials/ are
+// shared and not emitted, while other files there are copied and _*.tmpl
+// files elsewhere are rendered.
+func TestPartialsInTemplateDir(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "_partials/k8s/labels.tmpl", `{{ define "labels" }}app: {{ .name }}{{ end }}`)
+	writeFile(t, tmplDir, "_partials/team.tmpl", `{{ define "team" }}team: {{ .team }}{{ end }}`)
+	writeFile(t, tmplDir, "_partials/README.md", "shared")
+	writeFile(t, tmplDir, "_index.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "_helpers.tmpl", "{{ .team }}")
+	writeFile(t, tmplDir, "service.yaml.tmpl", "{{ template \"labels\" . }}\n{{ template \"team\" . }}\n")
+	writeFile(t, tmplDir, "deployment.yaml.tmpl", "{{ template \"labels\" . }}\n")
+
+	data := writeFile(t, dir, "data.json", `{"name": "api", "team": "core"}`)
+	outputDir := filepath.Join(dir, "output")
+
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	if got := readFile(t, filepath.Join(outputDir, "service.yaml")); got != "app: api\nteam: core\n" {
+		t.Errorf("service.yaml = %q", got)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "deployment.yaml")); got != "app: api\n" {
+		t.Errorf("deployment.yaml = %q", got)
+	}
+
+	for _, name := range []string{"_partials/team", "_partials/team.tmpl", "_partials/k8s"} {
+		if fileExists(filepath.Join(outputDir, name)) {
+			t.Errorf("partial %s should not be emitted", name)
+		}
+	}
+	want := map[string]string{"_partials/README.md": "shared", "_index.md": "# api", "_helpers": "core"}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(outputDir, name)); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
+
+// TestPartialsFlagFileMode tests --partials with a single template file.
+func TestPartialsFlagFileMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	partialsDir := filepath.Join(dir, "partials")
+	writeFile(t, partialsDir, "greeting.tmpl", `{{ define "greeting" }}Hello, {{ .name }}{{ end }}`)
+
+	tmpl := writeFile(t, dir, "template.txt", `{{ template "greeting" . }}!`)
+	data := writeFile(t, dir, "data.json", `{"name": "World"}`)
+	output := filepath.Join(dir, "output.txt")
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", output, "--partials", partialsDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	if got := readFile(t, output); got != "Hello, World!" {
+		t.Errorf("Output content = %q, want %q", got, "Hello, World!")
+	}
+}
+
+// TestPartialsFlagEachMode tests --partials in each mode.
+func TestPartialsFlagEachMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	partialsDir := filepath.Join(dir, "partials")
+	writeFile(t, partialsDir, "user.tmpl", `{{ define "user" }}{{ .name }} <{{ .email }}>{{ end }}`)
+
+	tmpl := writeFile(t, dir, "user.tmpl", `{{ template "user" . }}`)
+	data := writeFile(t, dir, "users.json", `[
+		{"name": "alice", "email": "alice@example.com"},
+		{"name": "bob", "email": "bob@example.com"}
+	]`)
+	outputDir := filepath.Join(dir, "output")
+
+	stdout, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(outputDir, "{{.name}}.txt"), "--partials", partialsDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+
+	if got := readFile(t, filepath.Join(outputDir, "alice.txt")); got != "alice <alice@example.com>" {
+		t.Errorf("alice.txt = %q", got)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "bob.txt")); got != "bob <bob@example.com>" {
+		t.Errorf("bob.txt = %q", got)
+	}
+}
+
+// TestPartialsFlagMissingDir tests that a missing --partials directory is an input error.
+func TestPartialsFlagMissingDir(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "template.txt", "x")
+	data := writeFile(t, dir, "data.json", `{}`)
+
+	_, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "out.txt"), "--partials", filepath.Join(dir, "nope"))
+	if code := getExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+	if !strings.Contains(stderr, "partials") {
+		t.Errorf("stderr should mention partials: %s", stderr)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "out.txt")); statErr == nil {
+		t.Error("output should not be written")
+	}
+}
@@ -0,0 +1,99 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestProvenanceRegenerate tests that a render recorded with --provenance
+// can be replayed with regenerate from another working directory.
+func TestProvenanceRegenerate(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplPath := writeFile(t, dir, "user.txt.tmpl", "{{ .name }} ({{ .role }})\n")
+	data := writeFile(t, dir, "data.json", `{"team": {"users": [{"name": "ann", "role": "dev"}, {"name": "bob", "role": "ops"}]}}`)
+	outputDir := filepath.Join(dir, "users")
+
+	_, stderr, err := runRender(t, tmplPath, data,
+		"--set", "team.users[1].role=sre", "--query", ".team", "--item-query", ".users[]",
+		"-o", filepath.Join(outputDir, "{{ .name }}.txt"), "--provenance")
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	var rec struct {
+		Template  string            `json:"template"`
+		Data      []string          `json:"data"`
+		Output    string            `json:"output"`
+		Set       []struct{}        `json:"set"`
+		Query     string            `json:"query"`
+		ItemQuery string            `json:"itemQuery"`
+		Inputs    map[string]string `json:"inputs"`
+	}
+	content := readFile(t, filepath.Join(outputDir, ".render-provenance.json"))
+	if err := json.Unmarshal([]byte(content), &rec); err != nil {
+		t.Fatalf("invalid provenance: %v\n%s", err, content)
+	}
+	if rec.Template != "../user.txt.tmpl" || len(rec.Data) != 1 || rec.Data[0] != "../data.json" || rec.Output != "{{ .name }}.txt" {
+		t.Errorf("unexpected paths in provenance: %s", content)
+	}
+	if rec.Query != ".team" || len(rec.Set) != 1 || rec.ItemQuery != ".users[]" || len(rec.Inputs) != 2 {
+		t.Errorf("unexpected inputs in provenance: %s", content)
+	}
+
+	// Regenerate from elsewhere after the data changed
+	writeFile(t, dir, "data.json", `{"team": {"users": [{"name": "ann", "role": "lead"}, {"name": "bob", "role": "ops"}]}}`)
+	cmd := []string{"regenerate", outputDir, "--force"}
+	stdout, stderr, err := runRenderIn(t, os.TempDir(), cmd...)
+	if err != nil {
+		t.Fatalf("regenerate failed: %v\nstdout: %s\nstderr: %s", err, stdout, stderr)
+	}
+	if !strings.Contains(stderr, "inputs changed") || !strings.Contains(stderr, "../data.json") {
+		t.Errorf("expected warning about changed data: %s", stderr)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "ann.txt")); got != "ann (lead)\n" {
+		t.Errorf("ann.txt = %q, want %q", got, "ann (lead)\n")
+	}
+	if got := readFile(t, filepath.Join(outputDir, "bob.txt")); got != "bob (sre)\n" {
+		t.Errorf("bob.txt = %q, want %q", got, "bob (sre)\n")
+	}
+
+	// The record was refreshed, so the output is up to date and unchanged
+	_, stderr, err = runRender(t, "regenerate", outputDir, "--check")
+	if err != nil {
+		t.Fatalf("regenerate --check failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "Warning") {
+		t.Errorf("unexpected warning after refresh: %s", stderr)
+	}
+}
+
+// TestRegenerateWithoutProvenance tests the error for a directory that
+// has no provenance file.
+func TestRegenerateWithoutProvenance(t *testing.T) {
+	dir := createTempDir(t)
+
+	_, stderr, err := runRender(t, "regenerate", dir)
+	if code := getExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "--provenance") {
+		t.Errorf("expected hint about --provenance: %s", stderr)
+	}
+}
+
+// TestProvenanceRequiresDirectoryOrEachMode tests that --provenance is
+// rejected for single-file renders.
+func TestProvenanceRequiresDirectoryOrEachMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "a.tmpl", "x")
+	data := writeFile(t, dir, "data.json", `{}`)
+	_, _, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "a.txt"), "--provenance")
+	if code := getExitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
@@ -0,0 +1,248 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestManifestWritten tests that directory mode records generated files in a manifest.
+func TestManifestWritten(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "config.yaml.tmpl", "name: {{ .name }}")
+	writeFile(t, tmplDir, "sub/static.txt", "static")
+
+	data := writeFile(t, dir, "data.json", `{"name": "app"}`)
+	outputDir := filepath.Join(dir, "output")
+
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	var m struct {
+		Files map[string]string `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(outputDir, ".render-manifest.json"))), &m); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	for _, path := range []string{"config.yaml", "sub/static.txt"} {
+		if !strings.HasPrefix(m.Files[path], "sha256:") {
+			t.Errorf("manifest missing hash for %s: %v", path, m.Files)
+		}
+	}
+
+	// The manifest is not a stale output
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--check"); err != nil {
+		t.Errorf("expected up to date: %v\nstderr: %s", err, stderr)
+	}
+}
+
+// TestPruneEachDirectory tests that --prune removes outputs of removed items
+// and keeps hand-edited files.
+func TestPruneEachDirectory(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+
+	outputDir := filepath.Join(dir, "services")
+	outputPath := filepath.Join(outputDir, "{{ .name }}")
+	data := writeFile(t, dir, "data.json", `[{"name": "api"}, {"name": "web"}, {"name": "worker"}]`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputPath); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	edited := filepath.Join(outputDir, "worker", "README.md")
+	if err := os.WriteFile(edited, []byte("# worker\n\nHand-written notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data = writeFile(t, dir, "data.json", `[{"name": "api"}]`)
+
+	// Dry run reports without deleting
+	stdout, stderr, err := runRender(t, tmplDir, data, "-o", outputPath, "--prune", "--dry-run")
+	if err != nil {
+		t.Fatalf("render --prune --dry-run failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "[prune] "+filepath.Join(outputDir, "web", "README.md")) {
+		t.Errorf("dry run should list web/README.md: %s", stdout)
+	}
+	if !fileExists(filepath.Join(outputDir, "web", "README.md")) {
+		t.Fatal("dry run should not delete files")
+	}
+
+	stdout, stderr, err = runRender(t, tmplDir, data, "-o", outputPath, "--prune", "--force")
+	if err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(outputDir, "web")) {
+		t.Error("web/ should be pruned")
+	}
+	if !fileExists(edited) {
+		t.Error("hand-edited file should be kept")
+	}
+	if !strings.Contains(stdout, "Kept (modified since generated): "+edited) {
+		t.Errorf("expected kept file to be reported: %s", stdout)
+	}
+	if !fileExists(filepath.Join(outputDir, "api", "README.md")) {
+		t.Error("api/README.md should still exist")
+	}
+}
+
+// TestPruneWithoutFlag tests that stale files are left alone without --prune
+// but can still be pruned by a later run.
+func TestPruneWithoutFlag(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .name }}")
+	outputDir := filepath.Join(dir, "users")
+	outputPath := filepath.Join(outputDir, "{{ .name }}.txt")
+
+	data := writeFile(t, dir, "data.json", `[{"name": "alice"}, {"name": "bob"}]`)
+	if _, stderr, err := runRender(t, tmpl, data, "-o", outputPath); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	data = writeFile(t, dir, "data.json", `[{"name": "alice"}]`)
+	if _, stderr, err := runRender(t, tmpl, data, "-o", outputPath); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if !fileExists(filepath.Join(outputDir, "bob.txt")) {
+		t.Fatal("bob.txt should be kept without --prune")
+	}
+
+	if _, stderr, err := runRender(t, tmpl, data, "-o", outputPath, "--prune"); err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(outputDir, "bob.txt")) {
+		t.Error("bob.txt should be pruned by a later --prune")
+	}
+}
+
+// TestManifestOutputsOutsideRoot tests that outputs an action places outside
+// the manifest's directory aren't recorded, so rendering again still works.
+func TestManifestOutputsOutsideRoot(t *testing.T) {
+	dir := createTempDir(t)
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .n }}")
+	outputDir := filepath.Join(dir, "abs", "o")
+	data, err := json.Marshal([]map[string]string{{"d": outputDir, "n": "a"}, {"d": outputDir, "n": "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataFile := writeFile(t, dir, "data.json", string(data))
+
+	for run := 1; run <= 2; run++ {
+		if _, stderr, err := runRenderCmd(t, workDir, nil, tmpl, dataFile, "-o", "{{ .d }}/{{ .n }}.txt"); err != nil {
+			t.Fatalf("run %d failed: %v\nstderr: %s", run, err, stderr)
+		}
+	}
+	if got := readFile(t, filepath.Join(outputDir, "a.txt")); got != "a" {
+		t.Errorf("a.txt = %q, want %q", got, "a")
+	}
+	if fileExists(filepath.Join(workDir, ".render-manifest.json")) {
+		t.Error("no manifest should be written for outputs outside its directory")
+	}
+}
+
+// TestManifestWorkingDirectory tests that an each-mode -o without a
+// directory part leaves no manifest in the working directory unless
+// --prune asks for one, and that a manifest kept there stays up to date.
+func TestManifestWorkingDirectory(t *testing.T) {
+	dir := createTempDir(t)
+	workDir := filepath.Join(dir, "work")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := writeFile(t, dir, "user.tmpl", "{{ .n }}")
+	both := writeFile(t, dir, "both.json", `[{"n": "a"}, {"n": "b"}]`)
+	onlyA := writeFile(t, dir, "a.json", `[{"n": "a"}]`)
+	manifestPath := filepath.Join(workDir, ".render-manifest.json")
+
+	if _, stderr, err := runRenderCmd(t, workDir, nil, tmpl, both, "-o", "{{ .n }}.txt"); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(manifestPath) {
+		t.Fatal("no manifest should be written to the working directory without --prune")
+	}
+
+	if _, stderr, err := runRenderCmd(t, workDir, nil, tmpl, both, "-o", "{{ .n }}.txt", "--prune"); err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+	if !fileExists(manifestPath) {
+		t.Fatal("--prune should write a manifest to the working directory")
+	}
+
+	// Once there, the manifest is kept up to date, so a later --prune works
+	if _, stderr, err := runRenderCmd(t, workDir, nil, tmpl, onlyA, "-o", "{{ .n }}.txt"); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runRenderCmd(t, workDir, nil, tmpl, onlyA, "-o", "{{ .n }}.txt", "--prune"); err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(workDir, "b.txt")) {
+		t.Error("b.txt should be pruned")
+	}
+}
+
+// TestManifestRecordsWritesBeforeFailure tests that the files written
+// before a failed write are recorded, so a later --prune can remove them.
+func TestManifestRecordsWritesBeforeFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		output  string // -o, relative to the test directory
+		root    string // Manifest directory, relative to the test directory
+		written string // File written before the failure, relative to root
+		blocker string // File in the way of the failing write, relative to root
+	}{
+		{name: "directory", data: `{"name": "one"}`, output: "output", root: "output", written: "a.txt", blocker: "z"},
+		{name: "each-directory", data: `[{"name": "one"}, {"name": "two"}]`, output: "output/{{ .name }}", root: "output", written: "one/a.txt", blocker: "two"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := createTempDir(t)
+			tmplDir := filepath.Join(dir, "templates")
+			writeFile(t, tmplDir, "a.txt.tmpl", "{{ .name }}\n")
+			writeFile(t, tmplDir, "z/c.txt.tmpl", "{{ .name }}\n")
+			data := writeFile(t, dir, "data.json", tc.data)
+			root := filepath.Join(dir, tc.root)
+			// A file where a directory is needed makes a later write fail
+			writeFile(t, root, tc.blocker, "in the way\n")
+
+			_, stderr, err := runRender(t, tmplDir, data, "-o", filepath.Join(dir, tc.output))
+			if code := getExitCode(err); code != 1 {
+				t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+			}
+
+			var m struct {
+				Files map[string]string `json:"files"`
+			}
+			if err := json.Unmarshal([]byte(readFile(t, filepath.Join(root, ".render-manifest.json"))), &m); err != nil {
+				t.Fatalf("invalid manifest: %v", err)
+			}
+			if _, ok := m.Files[tc.written]; !ok {
+				t.Errorf("manifest = %v, want %s recorded", m.Files, tc.written)
+			}
+		})
+	}
+}
+
+// TestPruneFileMode tests that --prune is rejected in single-file mode.
+func TestPruneFileMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "template.txt", "Hello!")
+	data := writeFile(t, dir, "data.json", `{}`)
+
+	_, _, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "out.txt"), "--prune")
+	if code := getExitCode(err); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
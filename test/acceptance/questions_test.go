@@ -0,0 +1,112 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const serviceQuestions = `questions:
+  - name: name
+    prompt: Service name
+    pattern: "^[a-z]+$"
+  - name: kind
+    choices: [http, grpc]
+    default: http
+  - name: port
+    type: int
+    default: 8080
+    when: '{{ eq .kind "http" }}'
+`
+
+// TestQuestionsAsked tests that the questions the data doesn't answer are
+// asked on stderr, and that regenerate replays the answers.
+func TestQuestionsAsked(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "service.txt.tmpl", "{{ .name }} {{ .kind }} {{ .port }} {{ .team }}")
+	writeFile(t, tmplDir, ".render.yaml", serviceQuestions)
+	data := writeFile(t, dir, "values.yaml", "team: ops\n")
+	outputDir := filepath.Join(dir, "out")
+
+	stdout, stderr, err := runRenderWithStdin(t, "Api\napi\n\n9090\n", tmplDir, data, "--provenance", "--json", "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, filepath.Join(outputDir, "service.txt")), "api http 9090 ops"; got != want {
+		t.Errorf("service.txt = %q, want %q", got, want)
+	}
+	for _, want := range []string{"Service name: ", `"Api" does not match`, "kind (http, grpc) [http]: ", "port [8080]: "} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in stderr: %s", want, stderr)
+		}
+	}
+	if strings.Contains(stdout, "Service name") {
+		t.Errorf("questions should not be asked on stdout: %s", stdout)
+	}
+
+	// The answers are recorded, so regenerate doesn't ask again
+	_, stderr, err = runRender(t, "regenerate", outputDir, "--check")
+	if err != nil {
+		t.Fatalf("regenerate --check failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
+// TestQuestionsNoInput tests that --no-input takes the defaults and lists
+// the questions without one.
+func TestQuestionsNoInput(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "service.txt.tmpl", "{{ .name }} {{ .kind }} {{ .port }}")
+	writeFile(t, tmplDir, ".render.yaml", serviceQuestions)
+	data := writeFile(t, dir, "values.yaml", "kind: grpc\n")
+	outputDir := filepath.Join(dir, "out")
+
+	_, stderr, err := runRender(t, tmplDir, data, "--no-input", "-o", outputDir)
+	if code := getExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "unanswered questions: name") || strings.Contains(stderr, "Service name:") {
+		t.Errorf("expected the unanswered question without asking it: %s", stderr)
+	}
+
+	_, stderr, err = runRender(t, tmplDir, data, "--no-input", "--set", "name=api", "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	// port is skipped for grpc, so it is never set
+	if got, want := readFile(t, filepath.Join(outputDir, "service.txt")), "api grpc <no value>"; got != want {
+		t.Errorf("service.txt = %q, want %q", got, want)
+	}
+}
+
+// TestQuestionsEachMode tests that items take the defaults and report their
+// unanswered questions.
+func TestQuestionsEachMode(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "service.txt.tmpl", "{{ .name }}:{{ .port }}")
+	writeFile(t, tmplDir, ".render.yaml", serviceQuestions)
+	outputDir := filepath.Join(dir, "out")
+
+	good := writeFile(t, dir, "good.yaml", "- name: api\n- name: web\n  port: 80\n")
+	_, stderr, err := runRender(t, tmplDir, good, "-o", filepath.Join(outputDir, "{{ .name }}"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	want := map[string]string{"api": "api:8080", "web": "web:80"}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(outputDir, name, "service.txt")); got != content {
+			t.Errorf("%s/service.txt = %q, want %q", name, got, content)
+		}
+	}
+
+	bad := writeFile(t, dir, "bad.yaml", "- name: api\n- port: 80\n")
+	_, stderr, err = runRender(t, tmplDir, bad, "-o", filepath.Join(dir, "bad", "{{ .port }}"))
+	if code := getExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "item 1 (.[1])") || !strings.Contains(stderr, "unanswered questions: name") {
+		t.Errorf("expected the item and its unanswered question in stderr: %s", stderr)
+	}
+}
@@ -0,0 +1,99 @@
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestProtectedRegionsPreserved tests that user code in protected regions
+// survives regeneration with --force.
+func TestProtectedRegionsPreserved(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "service.go.tmpl", `package {{ .pkg }}
+
+// render:keep begin imports
+// render:keep end
+
+func Name() string { return "{{ .name }}" }
+`)
+	data := writeFile(t, dir, "data.json", `{"pkg": "svc", "name": "api"}`)
+	output := filepath.Join(dir, "service.go")
+
+	if _, stderr, err := runRender(t, tmpl, data, "-o", output); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// A developer fills in the region
+	content := strings.Replace(readFile(t, output), "// render:keep begin imports\n", "// render:keep begin imports\nimport \"fmt\"\n", 1)
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data = writeFile(t, dir, "data.json", `{"pkg": "svc", "name": "web"}`)
+	if _, stderr, err := runRender(t, tmpl, data, "-o", output, "--force"); err != nil {
+		t.Fatalf("render --force failed: %v\nstderr: %s", err, stderr)
+	}
+
+	got := readFile(t, output)
+	if !strings.Contains(got, "// render:keep begin imports\nimport \"fmt\"\n// render:keep end") {
+		t.Errorf("region content lost:\n%s", got)
+	}
+	if !strings.Contains(got, `return "web"`) {
+		t.Errorf("generated code not updated:\n%s", got)
+	}
+}
+
+// TestProtectedRegionRemoved tests that regeneration fails without writing
+// when a region with user code is no longer in the template.
+func TestProtectedRegionRemoved(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "# render:keep begin notes\n# render:keep end\n{{ .v }}\n")
+	writeFile(t, tmplDir, "b.txt.tmpl", "{{ .v }}\n")
+
+	outputDir := filepath.Join(dir, "output")
+	writeFile(t, outputDir, "a.txt", "old\n")
+	writeFile(t, outputDir, "b.txt", "# render:keep begin notes\nkeep me\n# render:keep end\nold\n")
+
+	data := writeFile(t, dir, "data.json", `{"v": "new"}`)
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--force")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, `protected region "notes"`) || !strings.Contains(stderr, "b.txt") {
+		t.Errorf("expected missing region error for b.txt: %s", stderr)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "a.txt")); got != "old\n" {
+		t.Errorf("a.txt should not be written: %q", got)
+	}
+}
+
+// TestProtectedRegionMarkersConfig tests markers chosen per extension in .render.yaml.
+func TestProtectedRegionMarkersConfig(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, ".render.yaml", `regions:
+  ".sql":
+    begin: "-- BEGIN CUSTOM"
+    end: "-- END CUSTOM"
+`)
+	writeFile(t, tmplDir, "schema.sql.tmpl", "CREATE TABLE {{ .table }} (id int);\n-- BEGIN CUSTOM indexes\n-- END CUSTOM\n")
+
+	outputDir := filepath.Join(dir, "output")
+	writeFile(t, outputDir, "schema.sql", "CREATE TABLE old (id int);\n-- BEGIN CUSTOM indexes\nCREATE INDEX i ON old (id);\n-- END CUSTOM\n")
+
+	data := writeFile(t, dir, "data.json", `{"table": "users"}`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--force"); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	want := "CREATE TABLE users (id int);\n-- BEGIN CUSTOM indexes\nCREATE INDEX i ON old (id);\n-- END CUSTOM\n"
+	if got := readFile(t, filepath.Join(outputDir, "schema.sql")); got != want {
+		t.Errorf("schema.sql = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const serviceSchema = `{
+  "type": "object",
+  "required": ["name", "port"],
+  "properties": {
+    "name": {"type": "string"},
+    "port": {"type": "integer", "minimum": 1}
+  }
+}`
+
+// TestSchemaInTemplateDirectory tests that the schema the control file names
+// validates the data after --query and is not copied.
+func TestSchemaInTemplateDirectory(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}:{{ .port }}")
+	writeFile(t, tmplDir, "render.schema.json", serviceSchema)
+	writeFile(t, tmplDir, ".render.yaml", "schema: render.schema.json\n")
+	outputDir := filepath.Join(dir, "out")
+
+	good := writeFile(t, dir, "good.yaml", "service:\n  name: api\n  port: 8080\n")
+	_, stderr, err := runRender(t, tmplDir, good, "--query", ".service", "-o", outputDir)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(outputDir, "render.schema.json")) {
+		t.Error("render.schema.json should not be copied to the output")
+	}
+
+	bad := writeFile(t, dir, "bad.yaml", "service:\n  port: \"80\"\n")
+	_, stderr, err = runRender(t, tmplDir, bad, "--query", ".service", "-o", filepath.Join(dir, "bad"))
+	if code := getExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	for _, want := range []string{"data does not match schema", "2 violation(s)", `at "": missing property 'name'`, `at "/port": got string, want integer`} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in stderr: %s", want, stderr)
+		}
+	}
+	if fileExists(filepath.Join(dir, "bad")) {
+		t.Error("nothing should be written when the data doesn't match")
+	}
+}
+
+// TestSchemaEachMode tests that --schema validates every item in each mode
+// and reports each violation with its item.
+func TestSchemaEachMode(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "service.tmpl", "{{ .name }}")
+	schema := writeFile(t, dir, "service.schema.json", serviceSchema)
+	data := writeFile(t, dir, "services.json", `{"services": [{"name": "api", "port": 80}, {"name": "web", "port": 0}, {"port": 1}]}`)
+
+	stdout, stderr, err := runRender(t, tmpl, data, "--schema", schema, "--json",
+		"--item-query", ".services[]", "-o", filepath.Join(dir, "out", "{{ .name }}.txt"))
+	if code := getExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+
+	var result struct {
+		Errors []struct {
+			Message  string `json:"message"`
+			Source   string `json:"source"`
+			Item     *int   `json:"item"`
+			ItemPath string `json:"itemPath"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got: %s", stdout)
+	}
+	first, second := result.Errors[0], result.Errors[1]
+	if first.Item == nil || *first.Item != 1 || first.ItemPath != ".services[1]" || !strings.Contains(first.Message, `"/port"`) {
+		t.Errorf("unexpected first error: %+v", first)
+	}
+	if second.Item == nil || *second.Item != 2 || second.Source != "item 2 (.services[2])" {
+		t.Errorf("unexpected second error: %+v", second)
+	}
+	if fileExists(filepath.Join(dir, "out", "api.txt")) {
+		t.Error("nothing should be written when an item doesn't match")
+	}
+}
+
+// TestSchemaFromControlFile tests the schema: key in .render.yaml.
+func TestSchemaFromControlFile(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "schemas/service.json", serviceSchema)
+	writeFile(t, tmplDir, ".render.yaml", "schema: schemas/service.json\n")
+	data := writeFile(t, dir, "services.yaml", "- name: api\n  port: 80\n- name: web\n")
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", filepath.Join(dir, "out", "{{ .name }}"))
+	if code := getExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "item 1 (.[1])") || !strings.Contains(stderr, "missing property 'port'") {
+		t.Errorf("expected the failing item and violation in stderr: %s", stderr)
+	}
+
+	valid := writeFile(t, dir, "valid.yaml", "- name: api\n  port: 80\n")
+	_, stderr, err = runRender(t, tmplDir, valid, "-o", filepath.Join(dir, "out", "{{ .name }}"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(dir, "out", "api", "schemas", "service.json")) {
+		t.Error("the schema named by the control file should not be copied")
+	}
+}
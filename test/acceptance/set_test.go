@@ -0,0 +1,78 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSetFlags tests that --set style flags override loaded data in
+// command-line order, before --query.
+func TestSetFlags(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "out.tmpl",
+		`{{ .name }} {{ printf "%T" .replicas }} {{ printf "%T" .tag }} {{ .labels.tier }} {{ (index .services 1).port }} {{ .cert }}`)
+	data := writeFile(t, dir, "data.yaml", `app:
+  name: app
+  replicas: 1
+  services:
+    - name: api
+      port: 80
+    - name: web
+      port: 80
+`)
+	cert := writeFile(t, dir, "cert.pem", "CERT")
+	output := filepath.Join(dir, "out.txt")
+
+	_, stderr, err := runRender(t, tmpl, data, "--query", ".app",
+		"--set", "app.name=first",
+		"--set", "app.replicas=3",
+		"--set-string", "app.tag=1.20",
+		"--set-json", `app.labels={"tier": "backend"}`,
+		"--set", "app.services[1].port=8080",
+		"--set-file", "app.cert="+cert,
+		"--set", "app.name=svc",
+		"-o", output)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	want := "svc int string backend 8080 CERT"
+	if got := readFile(t, output); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+// TestSetFlagErrors tests errors for malformed --set style flags.
+func TestSetFlagErrors(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "a.tmpl", "x")
+	data := writeFile(t, dir, "data.json", `{"list": [1]}`)
+	output := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name string
+		flag []string
+		code int
+		want string
+	}{
+		{"missing value", []string{"--set", "name"}, 2, "expected key=value"},
+		{"bad path", []string{"--set", "a..b=1"}, 2, "empty key"},
+		{"index out of range", []string{"--set", "list[5]=1"}, 2, "out of range"},
+		{"invalid JSON", []string{"--set-json", "a={"}, 2, "invalid --set-json a"},
+		{"missing file", []string{"--set-file", "a=" + filepath.Join(dir, "missing")}, 3, "failed to read --set-file a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{tmpl, data, "-o", output}, tt.flag...)
+			_, stderr, err := runRender(t, args...)
+			if code := getExitCode(err); code != tt.code {
+				t.Errorf("exit code = %d, want %d\nstderr: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr: %s", tt.want, stderr)
+			}
+		})
+	}
+}
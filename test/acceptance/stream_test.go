@@ -0,0 +1,239 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStreamEachFile tests that JSON Lines items are rendered one file each,
+// with --item-query applied to every line.
+func TestStreamEachFile(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "user.txt.tmpl", "{{ .name }} in {{ .team }}")
+	data := writeFile(t, dir, "teams.jsonl",
+		`{"team": "ops", "users": [{"name": "ann"}, {"name": "bob"}]}`+"\n\n"+
+			`{"team": "dev", "users": [{"name": "cid"}]}`+"\n")
+	outputDir := filepath.Join(dir, "users")
+
+	stdout, stderr, err := runRender(t, tmpl, data, "--stream", "-j", "2",
+		"--item-query", ".team as $t | .users[] | {name, team: $t}",
+		"-o", filepath.Join(outputDir, "{{ .name }}.txt"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	want := map[string]string{"ann": "ann in ops", "bob": "bob in ops", "cid": "cid in dev"}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(outputDir, name+".txt")); got != content {
+			t.Errorf("%s.txt = %q, want %q", name, got, content)
+		}
+	}
+
+	// Actions are reported in item order
+	ann := strings.Index(stdout, "ann.txt")
+	cid := strings.Index(stdout, "cid.txt")
+	if ann < 0 || cid < ann {
+		t.Errorf("expected actions in item order: %s", stdout)
+	}
+	if !fileExists(filepath.Join(outputDir, ".render-manifest.json")) {
+		t.Error("expected a manifest in the output directory")
+	}
+}
+
+// TestStreamFromStdin tests streaming items from standard input, pruning the
+// outputs of items no longer present.
+func TestStreamFromStdin(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "item.tmpl", "{{ .id }}")
+	outputDir := filepath.Join(dir, "out")
+	output := filepath.Join(outputDir, "{{ .id }}.txt")
+
+	_, stderr, err := runRenderWithStdin(t, "{\"id\": 1}\n{\"id\": 2}\n", tmpl, "-", "--stream", "-o", output)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	stdout, stderr, err := runRenderWithStdin(t, "{\"id\": 1}\n", tmpl, "-", "--stream", "--prune", "--json", "-o", output)
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(outputDir, "2.txt")) {
+		t.Error("2.txt should have been pruned")
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Files  []struct {
+			Path   string `json:"path"`
+			Action string `json:"action"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if result.Status != "success" || len(result.Files) != 2 {
+		t.Fatalf("unexpected result: %s", stdout)
+	}
+	if result.Files[0].Action != "skipped (identical)" || result.Files[1].Action != "pruned" {
+		t.Errorf("unexpected actions: %s", stdout)
+	}
+}
+
+// TestStreamEachDirectory tests streaming items into each-directory mode.
+func TestStreamEachDirectory(t *testing.T) {
+	dir := createTempDir(t)
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "README.md.tmpl", "# {{ .name }}")
+	writeFile(t, tmplDir, "LICENSE", "MIT")
+	data := writeFile(t, dir, "services.ndjson", "{\"name\": \"api\"}\n{\"name\": \"web\"}\n")
+	outputDir := filepath.Join(dir, "services")
+
+	_, stderr, err := runRender(t, tmplDir, data, "--stream", "-o", filepath.Join(outputDir, "{{ .name }}"))
+	if err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	for _, name := range []string{"api", "web"} {
+		if got := readFile(t, filepath.Join(outputDir, name, "README.md")); got != "# "+name {
+			t.Errorf("%s/README.md = %q", name, got)
+		}
+		if got := readFile(t, filepath.Join(outputDir, name, "LICENSE")); got != "MIT" {
+			t.Errorf("%s/LICENSE = %q", name, got)
+		}
+	}
+}
+
+// TestStreamErrors tests collisions, bad lines, failing items and schema
+// violations, which report the line of the data they came from.
+func TestStreamErrors(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "item.tmpl", "{{ .id }}")
+	schema := writeFile(t, dir, "item.schema.json", `{"properties": {"id": {"type": "integer"}}}`)
+
+	tests := []struct {
+		name  string
+		lines string
+		args  []string
+		code  int
+		want  string
+	}{
+		{
+			name:  "collision",
+			lines: "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 1}\n",
+			code:  1,
+			want:  "internal collision: items at index 0 and 2",
+		},
+		{
+			name:  "bad line",
+			lines: "{\"id\": 1}\n{\"id\": \n",
+			code:  3,
+			want:  "line 2",
+		},
+		{
+			name:  "failing item",
+			lines: "{\"id\": 1}\n\n{\"name\": \"x\"}\n",
+			args:  []string{"--strict"},
+			code:  1,
+			want:  "item 1 (line 3)",
+		},
+		{
+			name:  "schema violation",
+			lines: "{\"id\": 1}\n{\"id\": \"x\"}\n",
+			args:  []string{"--schema", schema},
+			code:  3,
+			want:  "item 1 (line 2):\n  - at \"/id\": got string, want integer",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeFile(t, dir, tt.name+".jsonl", tt.lines)
+			output := filepath.Join(dir, "out", string(rune('a'+i)), "{{ .id }}.txt")
+			args := append([]string{tmpl, data, "--stream", "-o", output}, tt.args...)
+			_, stderr, err := runRender(t, args...)
+			if code := getExitCode(err); code != tt.code {
+				t.Errorf("exit code = %d, want %d\nstderr: %s", code, tt.code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr: %s", tt.want, stderr)
+			}
+		})
+	}
+}
+
+// TestStreamFailureAfterWrites tests that items written before a failure
+// are recorded in the manifest, or rolled back with --transactional.
+func TestStreamFailureAfterWrites(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "item.tmpl", "{{ .id }}")
+	data := writeFile(t, dir, "items.jsonl", "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 1}\n")
+
+	outputDir := filepath.Join(dir, "out")
+	output := filepath.Join(outputDir, "{{ .id }}.txt")
+	_, stderr, err := runRender(t, tmpl, data, "--stream", "--jobs", "1", "-o", output)
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	manifest := readFile(t, filepath.Join(outputDir, ".render-manifest.json"))
+	for _, name := range []string{"1.txt", "2.txt"} {
+		if !fileExists(filepath.Join(outputDir, name)) || !strings.Contains(manifest, name) {
+			t.Errorf("%s should be written and in the manifest: %s", name, manifest)
+		}
+	}
+
+	// The written items can be pruned once the data is fixed
+	data = writeFile(t, dir, "items.jsonl", "{\"id\": 1}\n")
+	if _, stderr, err := runRender(t, tmpl, data, "--stream", "--prune", "-o", output); err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+	if fileExists(filepath.Join(outputDir, "2.txt")) {
+		t.Error("2.txt should be pruned")
+	}
+
+	data = writeFile(t, dir, "items.jsonl", "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 1}\n")
+	txDir := filepath.Join(dir, "tx")
+	_, stderr, err = runRender(t, tmpl, data, "--stream", "--jobs", "1", "--transactional", "-o", filepath.Join(txDir, "{{ .id }}.txt"))
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if fileExists(txDir) {
+		t.Error("--transactional should roll back the items written before the failure")
+	}
+}
+
+// TestStreamUsageErrors tests flags that can't be combined with --stream.
+func TestStreamUsageErrors(t *testing.T) {
+	dir := createTempDir(t)
+	tmpl := writeFile(t, dir, "item.tmpl", "{{ .id }}")
+	data := writeFile(t, dir, "items.jsonl", "{\"id\": 1}\n")
+	yaml := writeFile(t, dir, "items.yaml", "- id: 1\n")
+	each := filepath.Join(dir, "out", "{{ .id }}.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"not each mode", []string{data, "-o", filepath.Join(dir, "out.txt")}, "--stream requires each mode"},
+		{"not JSON Lines", []string{yaml, "-o", each}, "--stream requires JSON Lines data"},
+		{"several sources", []string{data, "--data", data, "-o", each}, "exactly one data source"},
+		{"query", []string{data, "--query", ".", "-o", each}, "use --item-query"},
+		{"set", []string{data, "--set", "a=1", "-o", each}, "--set style flags"},
+		{"check", []string{data, "--check", "-o", each}, "--check or --diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{tmpl, "--stream"}, tt.args...)
+			_, stderr, err := runRender(t, args...)
+			if code := getExitCode(err); code != 2 {
+				t.Errorf("exit code = %d, want 2\nstderr: %s", code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr: %s", tt.want, stderr)
+			}
+		})
+	}
+}
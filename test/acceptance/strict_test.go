@@ -0,0 +1,96 @@
+package acceptance
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStrictFileMode tests that --strict fails on a missing key and names the file and line.
+func TestStrictFileMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "config.tmpl", "name: {{ .name }}\nport: {{ .server.port }}\n")
+	data := writeFile(t, dir, "data.json", `{"name": "app", "server": {}}`)
+	output := filepath.Join(dir, "config.yaml")
+
+	// Without --strict the missing key renders as <no value>
+	if _, stderr, err := runRender(t, tmpl, data, "-o", output); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+	if content := readFile(t, output); !strings.Contains(content, "<no value>") {
+		t.Errorf("expected <no value> without --strict, got %q", content)
+	}
+
+	strictOutput := filepath.Join(dir, "strict.yaml")
+	_, stderr, err := runRender(t, tmpl, data, "-o", strictOutput, "--strict")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	for _, want := range []string{"config.tmpl:2:", `missing key "port"`, ".server.port"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q: %s", want, stderr)
+		}
+	}
+	if fileExists(strictOutput) {
+		t.Error("output should not be written on strict failure")
+	}
+}
+
+// TestStrictControlFile tests strict: true in .render.yaml, including path mappings.
+func TestStrictControlFile(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, ".render.yaml", `strict: true
+paths:
+  "model.go.tmpl": "{{ .model }}.go"
+`)
+	writeFile(t, tmplDir, "model.go.tmpl", "package {{ .name }}")
+
+	data := writeFile(t, dir, "data.json", `{"name": "app"}`)
+	outputDir := filepath.Join(dir, "output")
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputDir)
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, `missing key "model"`) {
+		t.Errorf("stderr should report missing path key: %s", stderr)
+	}
+}
+
+// TestStrictDirMode tests --strict with nested templates in directory mode.
+func TestStrictDirMode(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "ok.txt.tmpl", "{{ .name }}")
+	writeFile(t, tmplDir, "sub/bad.txt.tmpl", "line one\n{{ .missing }}")
+
+	data := writeFile(t, dir, "data.json", `{"name": "app"}`)
+
+	_, stderr, err := runRender(t, tmplDir, data, "-o", filepath.Join(dir, "output"), "--strict")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "sub/bad.txt.tmpl:2:") {
+		t.Errorf("stderr should name the template file and line: %s", stderr)
+	}
+}
+
+// TestStrictOutputPath tests --strict applies to the dynamic -o template.
+func TestStrictOutputPath(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmpl := writeFile(t, dir, "item.tmpl", "{{ .id }}")
+	data := writeFile(t, dir, "data.json", `[{"id": 1, "name": "a"}, {"id": 2}]`)
+
+	_, stderr, err := runRender(t, tmpl, data, "-o", filepath.Join(dir, "out", "{{.name}}.txt"), "--strict")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "output path") || !strings.Contains(stderr, `missing key "name"`) {
+		t.Errorf("stderr should report missing key in output path: %s", stderr)
+	}
+}
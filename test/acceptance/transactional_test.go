@@ -0,0 +1,98 @@
+package acceptance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestTransactionalRollback tests that a write failure part-way through a
+// directory render restores the files already written.
+func TestTransactionalRollback(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .v }}\n")
+	writeFile(t, tmplDir, "b.txt.tmpl", "{{ .v }}\n")
+	writeFile(t, tmplDir, "z/c.txt.tmpl", "{{ .v }}\n")
+
+	outputDir := filepath.Join(dir, "output")
+	writeFile(t, outputDir, "a.txt", "old\n")
+	// A file where a directory is needed makes the last write fail
+	writeFile(t, outputDir, "z", "not a directory\n")
+
+	data := writeFile(t, dir, "data.json", `{"v": "new"}`)
+	_, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--force", "--transactional")
+	if code := getExitCode(err); code != 1 {
+		t.Fatalf("exit code = %d, want 1\nstderr: %s", code, stderr)
+	}
+
+	if got := readFile(t, filepath.Join(outputDir, "a.txt")); got != "old\n" {
+		t.Errorf("a.txt = %q, want restored %q", got, "old\n")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "b.txt")); !os.IsNotExist(err) {
+		t.Error("b.txt should be removed by the rollback")
+	}
+
+	entries, _ := os.ReadDir(outputDir)
+	if len(entries) != 2 {
+		t.Errorf("expected only a.txt and z in output, got %d entries", len(entries))
+	}
+}
+
+// TestTransactionalSuccess tests that a successful transactional render
+// leaves no backup files behind.
+func TestTransactionalSuccess(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .v }}\n")
+
+	outputDir := filepath.Join(dir, "output")
+	writeFile(t, outputDir, "a.txt", "old\n")
+
+	data := writeFile(t, dir, "data.json", `{"v": "new"}`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--force", "--transactional"); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	if got := readFile(t, filepath.Join(outputDir, "a.txt")); got != "new\n" {
+		t.Errorf("a.txt = %q, want %q", got, "new\n")
+	}
+	entries, _ := os.ReadDir(outputDir)
+	for _, e := range entries {
+		if e.Name() != "a.txt" && e.Name() != ".render-manifest.json" {
+			t.Errorf("unexpected file left in output: %s", e.Name())
+		}
+	}
+}
+
+// TestTransactionalPrune tests that files pruned in a transaction are gone,
+// along with their directories and backups, once it succeeds.
+func TestTransactionalPrune(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .v }}\n")
+	writeFile(t, tmplDir, "old/b.txt.tmpl", "{{ .v }}\n")
+
+	outputDir := filepath.Join(dir, "output")
+	data := writeFile(t, dir, "data.json", `{"v": "new"}`)
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir); err != nil {
+		t.Fatalf("render failed: %v\nstderr: %s", err, stderr)
+	}
+
+	if err := os.RemoveAll(filepath.Join(tmplDir, "old")); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--force", "--transactional", "--prune"); err != nil {
+		t.Fatalf("render --prune failed: %v\nstderr: %s", err, stderr)
+	}
+
+	entries, _ := os.ReadDir(outputDir)
+	for _, e := range entries {
+		if e.Name() != "a.txt" && e.Name() != ".render-manifest.json" {
+			t.Errorf("unexpected file left in output: %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// generateWithSnapshot renders tmplDir into a new output directory with
+// --snapshot and returns the output directory.
+func generateWithSnapshot(t *testing.T, dir, tmplDir, data string) string {
+	t.Helper()
+	outputDir := filepath.Join(dir, "output")
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--snapshot"); err != nil {
+		t.Fatalf("render --snapshot failed: %v\nstderr: %s", err, stderr)
+	}
+	return outputDir
+}
+
+// TestUpdateMergesTemplateChanges tests that template changes are merged
+// into locally edited files without losing the edits.
+func TestUpdateMergesTemplateChanges(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "main.go.tmpl", "package {{ .pkg }}\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n")
+	writeFile(t, tmplDir, "VERSION.tmpl", "1\n")
+	data := writeFile(t, dir, "data.json", `{"pkg": "main"}`)
+	outputDir := generateWithSnapshot(t, dir, tmplDir, data)
+
+	// A developer adds code; the template gains a comment at the top
+	writeFile(t, outputDir, "main.go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\nfunc extra() {}\n")
+	writeFile(t, tmplDir, "main.go.tmpl", "// Code generated from a template.\npackage {{ .pkg }}\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n")
+	writeFile(t, tmplDir, "VERSION.tmpl", "2\n")
+	writeFile(t, tmplDir, "LICENSE", "MIT\n")
+
+	stdout, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("update failed: %v\nstderr: %s", err, stderr)
+	}
+
+	want := "// Code generated from a template.\npackage main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\nfunc extra() {}\n"
+	if got := readFile(t, filepath.Join(outputDir, "main.go")); got != want {
+		t.Errorf("main.go =\n%s\nwant:\n%s", got, want)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "VERSION")); got != "2\n" {
+		t.Errorf("VERSION = %q, want %q", got, "2\n")
+	}
+	if got := readFile(t, filepath.Join(outputDir, "LICENSE")); got != "MIT\n" {
+		t.Errorf("LICENSE = %q, want %q", got, "MIT\n")
+	}
+	for _, line := range []string{"[merge]", "[update]", "[create]"} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("expected %s in output: %s", line, stdout)
+		}
+	}
+
+	// The snapshot now holds the new templates, so a second update is a no-op
+	stdout, stderr, err = runRender(t, "update", tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("second update failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.TrimSpace(stdout) != "" {
+		t.Errorf("expected nothing to do, got: %s", stdout)
+	}
+}
+
+// TestUpdateConflict tests that conflicting changes are written with
+// conflict markers and reported in the JSON output.
+func TestUpdateConflict(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "config.yaml.tmpl", "name: {{ .name }}\nport: 80\n")
+	data := writeFile(t, dir, "data.json", `{"name": "api"}`)
+	outputDir := generateWithSnapshot(t, dir, tmplDir, data)
+
+	writeFile(t, outputDir, "config.yaml", "name: api\nport: 8080\n")
+	writeFile(t, tmplDir, "config.yaml.tmpl", "name: {{ .name }}\nport: 443\n")
+
+	stdout, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir, "--json")
+	if code := getExitCode(err); code != 5 {
+		t.Fatalf("exit code = %d, want 5\nstderr: %s", code, stderr)
+	}
+
+	var result struct {
+		Status    string   `json:"status"`
+		Conflicts []string `json:"conflicts"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Status != "conflict" {
+		t.Errorf("status = %q, want conflict", result.Status)
+	}
+	if len(result.Conflicts) != 1 || filepath.Base(result.Conflicts[0]) != "config.yaml" {
+		t.Errorf("conflicts = %v, want config.yaml", result.Conflicts)
+	}
+
+	want := "name: api\n<<<<<<< current\nport: 8080\n=======\nport: 443\n>>>>>>> template\n"
+	if got := readFile(t, filepath.Join(outputDir, "config.yaml")); got != want {
+		t.Errorf("config.yaml =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestUpdateKeepsNumberTypes tests that integers in the snapshot data are
+// still integers when update renders the previous version again.
+func TestUpdateKeepsNumberTypes(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "deploy.yaml.tmpl", "replicas: {{ .replicas }}\n{{ if eq .replicas 1500000 }}size: large\n{{ end }}port: 80\n")
+	data := writeFile(t, dir, "data.yaml", "replicas: 1500000\n")
+	outputDir := generateWithSnapshot(t, dir, tmplDir, data)
+
+	writeFile(t, outputDir, "deploy.yaml", "# edited\nreplicas: 1500000\nsize: large\nport: 80\n")
+	writeFile(t, tmplDir, "deploy.yaml.tmpl", "replicas: {{ .replicas }}\n{{ if eq .replicas 1500000 }}size: large\n{{ end }}port: 443\n")
+
+	_, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("update failed: %v\nstderr: %s", err, stderr)
+	}
+
+	want := "# edited\nreplicas: 1500000\nsize: large\nport: 443\n"
+	if got := readFile(t, filepath.Join(outputDir, "deploy.yaml")); got != want {
+		t.Errorf("deploy.yaml =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestUpdateQuestions tests that update reuses the answers of the previous
+// render, asks only new questions, and checks the data against the schema.
+func TestUpdateQuestions(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "service")
+	writeFile(t, tmplDir, "service.txt.tmpl", "{{ .name }} {{ .kind }} {{ .port }}\n")
+	writeFile(t, tmplDir, ".render.yaml", serviceQuestions)
+	data := writeFile(t, dir, "values.yaml", "team: ops\n")
+	outputDir := filepath.Join(dir, "output")
+	if _, stderr, err := runRenderWithStdin(t, "api\n\n9090\n", tmplDir, data, "-o", outputDir, "--snapshot"); err != nil {
+		t.Fatalf("render --snapshot failed: %v\nstderr: %s", err, stderr)
+	}
+
+	// The template gains a question and a schema for the port
+	writeFile(t, tmplDir, "service.txt.tmpl", "{{ .name }} {{ .kind }} {{ .port }} {{ .owner }}\n")
+	writeFile(t, tmplDir, ".render.yaml", serviceQuestions+"  - name: owner\nschema: render.schema.json\n")
+	writeFile(t, tmplDir, "render.schema.json", `{"properties": {"port": {"type": "integer", "maximum": 65535}}}`)
+
+	_, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir, "--no-input", "--set", "owner=ops", "--set", "port=70000")
+	if code := getExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "port") {
+		t.Errorf("expected the schema violation for port: %s", stderr)
+	}
+
+	_, stderr, err = runRenderWithStdin(t, "alice\n", "update", tmplDir, data, "-o", outputDir)
+	if err != nil {
+		t.Fatalf("update failed: %v\nstderr: %s", err, stderr)
+	}
+	if got, want := readFile(t, filepath.Join(outputDir, "service.txt")), "api http 9090 alice\n"; got != want {
+		t.Errorf("service.txt = %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr, "owner: ") || strings.Contains(stderr, "Service name") {
+		t.Errorf("expected only the new question to be asked: %s", stderr)
+	}
+}
+
+// TestUpdateSkipEmpty tests that update leaves out blank templates when
+// the previous render did, unless told otherwise.
+func TestUpdateSkipEmpty(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "1\n")
+	writeFile(t, tmplDir, "extra.txt.tmpl", "{{ if .extra }}extra{{ end }}\n")
+	data := writeFile(t, dir, "data.json", `{"extra": false}`)
+	outputDir := filepath.Join(dir, "output")
+	if _, stderr, err := runRender(t, tmplDir, data, "-o", outputDir, "--snapshot", "--skip-empty"); err != nil {
+		t.Fatalf("render --snapshot failed: %v\nstderr: %s", err, stderr)
+	}
+
+	writeFile(t, tmplDir, "a.txt.tmpl", "2\n")
+	if _, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir); err != nil {
+		t.Fatalf("update failed: %v\nstderr: %s", err, stderr)
+	}
+	if got := readFile(t, filepath.Join(outputDir, "a.txt")); got != "2\n" {
+		t.Errorf("a.txt = %q, want %q", got, "2\n")
+	}
+	if fileExists(filepath.Join(outputDir, "extra.txt")) {
+		t.Error("extra.txt should still be skipped as empty")
+	}
+
+	if _, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir, "--skip-empty=false"); err != nil {
+		t.Fatalf("update --skip-empty=false failed: %v\nstderr: %s", err, stderr)
+	}
+	if !fileExists(filepath.Join(outputDir, "extra.txt")) {
+		t.Error("extra.txt should be created with --skip-empty=false")
+	}
+}
+
+// TestUpdateRequiresSnapshot tests that update fails for a directory
+// rendered without --snapshot.
+func TestUpdateRequiresSnapshot(t *testing.T) {
+	dir := createTempDir(t)
+
+	tmplDir := filepath.Join(dir, "templates")
+	writeFile(t, tmplDir, "a.txt.tmpl", "{{ .v }}\n")
+	data := writeFile(t, dir, "data.json", `{"v": "x"}`)
+	outputDir := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := runRender(t, "update", tmplDir, data, "-o", outputDir)
+	if code := getExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d, want 3\nstderr: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "--snapshot") {
+		t.Errorf("expected hint about --snapshot: %s", stderr)
+	}
+}